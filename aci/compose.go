@@ -124,6 +124,14 @@ func (cs *aciComposeService) Down(ctx context.Context, projectName string, optio
 	return err
 }
 
+func (cs *aciComposeService) EstimateDown(ctx context.Context, projectName string, options compose.DownOptions) (*compose.DownEstimate, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+func (cs *aciComposeService) Tombstone(ctx context.Context, projectName string) (*compose.Tombstone, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
 func (cs *aciComposeService) Ps(ctx context.Context, project string) ([]compose.ContainerSummary, error) {
 	groupsClient, err := login.NewContainerGroupsClient(cs.ctx.SubscriptionID)
 	if err != nil {
@@ -60,6 +60,14 @@ func (c *composeService) Down(context.Context, string, compose.DownOptions) erro
 	return errdefs.ErrNotImplemented
 }
 
+func (c *composeService) EstimateDown(context.Context, string, compose.DownOptions) (*compose.DownEstimate, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+func (c *composeService) Tombstone(context.Context, string) (*compose.Tombstone, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
 func (c *composeService) Logs(context.Context, string, compose.LogConsumer, compose.LogOptions) error {
 	return errdefs.ErrNotImplemented
 }
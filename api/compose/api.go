@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/compose-spec/compose-go/types"
 )
@@ -41,6 +42,10 @@ type Service interface {
 	Up(ctx context.Context, project *types.Project, options UpOptions) error
 	// Down executes the equivalent to a `compose down`
 	Down(ctx context.Context, projectName string, options DownOptions) error
+	// EstimateDown computes the number of resources a `compose down` would remove
+	EstimateDown(ctx context.Context, projectName string, options DownOptions) (*DownEstimate, error)
+	// Tombstone returns the record of the project intentional teardown, nil if the project was never torn down
+	Tombstone(ctx context.Context, projectName string) (*Tombstone, error)
 	// Logs executes the equivalent to a `compose logs`
 	Logs(ctx context.Context, projectName string, consumer LogConsumer, options LogOptions) error
 	// Ps executes the equivalent to a `compose ps`
@@ -57,6 +62,11 @@ type Service interface {
 type CreateOptions struct {
 	// Remove legacy containers for services that are not defined in the project
 	RemoveOrphans bool
+	// ContextMetadata records the project model location in the metadata directory of the docker context the project is
+	// created with, removed by a down with RemoveContextMetadata
+	ContextMetadata bool
+	// RecordProject records the project into the local project store, for it to be listed without reaching the daemon
+	RecordProject bool
 }
 
 // UpOptions group options of the Up API
@@ -71,6 +81,312 @@ type DownOptions struct {
 	RemoveOrphans bool
 	// Project is the compose project used to define this app. Might be nil if user ran `down` just with project name
 	Project *types.Project
+	// DryRunThenExecute will check all teardown preconditions before removing any resource
+	DryRunThenExecute bool
+	// RemoveContextMetadata will cleanup metadata compose stored for the docker context the project was created with, see
+	// CreateOptions.ContextMetadata
+	RemoveContextMetadata bool
+	// ProjectFile is a previously exported compose model used to define this app when Project is nil
+	ProjectFile string
+	// Result, if set, is populated with a summary of the removed resources
+	Result *DownResult
+	// PruneDangling will remove dangling images left over by the project builds
+	PruneDangling bool
+	// ServiceGroupLabel is a container label grouping services. When set, only services of ServiceGroup are removed
+	ServiceGroupLabel string
+	// ServiceGroup is the value of ServiceGroupLabel for the services to remove
+	ServiceGroup string
+	// DiagnosticsTo is the path of a tar.gz bundle to collect project diagnostics into before teardown
+	DiagnosticsTo string
+	// MatchServiceByName attributes containers missing the service label to services by the `<project>_<service>_<index>` naming convention
+	MatchServiceByName bool
+	// CheckFirewallRules reports host firewall rules still referring to the subnets of removed networks
+	CheckFirewallRules bool
+	// RemovalStrategy defines the order and method to remove resources. Default one removes resources in parallel
+	RemovalStrategy RemovalStrategy
+	// CommitBeforeRemove will commit containers to an image before they get removed
+	CommitBeforeRemove bool
+	// Drain, if set, notifies running containers they're about to be stopped, then waits for a grace period
+	Drain *DrainOptions
+	// CleanupResiduals only removes the networks left over by a previous teardown, once no container remains. Volumes
+	// are removed as well when Volumes is set
+	CleanupResiduals bool
+	// ArchiveImagesPrefix, if set, retags images built for the project under this prefix before removing their project tag
+	ArchiveImagesPrefix string
+	// Volumes will remove named volumes declared by the project and anonymous volumes attached to containers
+	Volumes bool
+	// VerifyVolumesRemoved checks removed anonymous volumes no longer exist
+	VerifyVolumesRemoved bool
+	// ServiceResolver maps containers to services for stacks not relying on compose labels, typically imported from another tool
+	ServiceResolver ServiceResolver
+	// Confirm, if set, is called before irreversible steps. Declined steps are skipped
+	Confirm func(action DestructiveAction) (bool, error)
+	// HistoryFile, if set, is the path of a local history file a JSON line recording the teardown is appended to
+	HistoryFile string
+	// ResourceOrder is the order networks and volumes are removed in, once containers are removed
+	ResourceOrder ResourceOrder
+	// Tombstone will record the project was intentionally torn down, see Service.Tombstone
+	Tombstone bool
+	// RemoveImages selects images used by services to remove, either RemoveImagesAll or RemoveImagesLocal
+	RemoveImages string
+	// ServiceReplicas, if set, restricts the teardown to replicas of services by index, keeping other replicas running
+	ServiceReplicas map[string][]int
+	// AutoRetryWhole runs the whole teardown again, a bounded number of times, while project resources remain afterwards
+	AutoRetryWhole bool
+	// MetricsTo, if set, is a file path or a pushgateway URL teardown metrics are written to in Prometheus text format
+	MetricsTo string
+	// WaitStopped waits for the daemon to report stopped containers as not running before they get removed, for up to
+	// MaxStopDuration, or 10s if not set
+	WaitStopped bool
+	// PreserveSharedDefault keeps the project default network when it is external or still used by other projects
+	PreserveSharedDefault bool
+	// MaskEvents redacts resource names from progress events, e.g. "Container 1", for output going to shared logs
+	MaskEvents bool
+	// PostDownCommand, if set, is a command run after a successful teardown, with the project name and removed resources
+	// counts as COMPOSE_* environment variables
+	PostDownCommand []string
+	// PostDownCommandFatal makes a failing PostDownCommand fail the teardown, instead of only reporting a warning
+	PostDownCommandFatal bool
+	// CheckHeldPorts checks host ports published by the project are free once it is torn down, a port still held
+	// usually is a stuck userland-proxy
+	CheckHeldPorts bool
+	// InferVolumeOrder removes services mounting a named volume read-only before the service writing to it. This is a
+	// heuristic for the init-container pattern, compose doesn't formalize such dependencies
+	InferVolumeOrder bool
+	// UndoScript, if set, is the path of a shell script recreating the project, written before anything is removed
+	UndoScript string
+	// RateLimit, if set, is the maximum number of daemon calls per second made by the teardown
+	RateLimit float64
+	// AuditSink, if set, records every destructive action of the teardown, once it completes
+	AuditSink AuditSink
+	// OlderThan, if set, restricts the teardown to containers created more than this duration ago. Shared resources are
+	// kept while recent containers remain
+	OlderThan time.Duration
+	// ReportReclaimedSpace estimates the disk space freed by removed volumes and images, sizes being gathered before removal
+	ReportReclaimedSpace bool
+	// VerifyContainersRemoved confirms each removed container is gone, from the daemon destroy events or by polling when the
+	// event stream is unavailable
+	VerifyContainersRemoved bool
+	// Placeholders leaves a stopped container per removed service, labeled like the service containers, for external
+	// reconcilers to revive services from. Placeholders left by a previous teardown are kept
+	Placeholders bool
+	// ValidateLabels warns, before anything is removed, about project containers with missing or inconsistent labels which
+	// could make a future reconstruction of the project fail
+	ValidateLabels bool
+	// Notify shows a desktop notification summarizing the teardown once it completes, when the platform supports it
+	Notify bool
+	// RemoveCheckpoints deletes the checkpoints of containers before removing them, as they would be orphaned on the daemon
+	RemoveCheckpoints bool
+	// RecordEventsTo, if set, is the path of a file recording progress events as JSON lines, for the teardown UI to be
+	// replayed with progress.Replay
+	RecordEventsTo string
+	// CheckWritable probes the daemon accepts changes before anything is removed, to fail with a single clear error when
+	// it is read-only
+	CheckWritable bool
+	// ServiceTeardown, if set, is called for each service in reverse dependency order, before its containers are removed,
+	// to decide how the service is torn down
+	ServiceTeardown ServiceTeardown
+	// CollectStats captures a last snapshot of the resource usage of running containers before they get stopped. Stats
+	// failing to be collected in time are only reported as warnings
+	CollectStats bool
+	// NetworkGracePeriod, if set, is a delay before networks are removed once containers are gone, for DNS lookups and
+	// connections of sibling stacks on shared networks to drain
+	NetworkGracePeriod time.Duration
+	// DesiredStateFile, if set, is the path of a YAML or JSON descriptor listing the services which should not exist, as
+	// `absent: [service, ...]`, optionally checked against the project name with `project: name`. Only those services are
+	// removed, resources shared by the project are kept
+	DesiredStateFile string
+	// StopCheck, if set, is a command which must succeed inside running containers before they get stopped. Containers
+	// for which it doesn't succeed in time are killed
+	StopCheck *StopCheckOptions
+	// VolumeDrivers, if set, restricts the removal of project volumes to volumes using one of these drivers. Anonymous
+	// volumes removed along with their containers are not filtered
+	VolumeDrivers []string
+	// VerifyDependencyGraph warns, before anything is removed, about links, network modes and volumes_from between
+	// containers the model dependencies don't declare, as the teardown order could then be wrong
+	VerifyDependencyGraph bool
+	// MaxStopDuration, if set, is the time after which a container stop not returning, as the daemon is unresponsive, is
+	// abandoned with a warning. This is unrelated to the stop timeout after which the daemon kills the container
+	MaxStopDuration time.Duration
+	// DiffHistory reports, before anything is removed, how the project differs from its last teardown recorded in
+	// HistoryFile: new and removed services, and changed images
+	DiffHistory bool
+	// KeepStates, if set, keeps containers in one of these states, e.g. "running", so that only crashed or stopped replicas
+	// get removed. Shared resources are kept while containers remain
+	KeepStates []string
+	// MemoryOrder removes containers one at a time within each dependency tier, starting with the ones using the most
+	// memory so that RAM is freed as soon as possible on hosts under memory pressure
+	MemoryOrder bool
+	// VerifySubnetsReleased checks the IPAM driver released the static subnets of removed networks, reporting the ones
+	// which appear stuck and would conflict with a later up
+	VerifySubnetsReleased bool
+	// TeardownGracePeriod, if set, keeps removing resources for up to this duration once the context is cancelled, so
+	// that an interrupted teardown doesn't leave the project half removed. Ctrl-C then takes as long to return
+	TeardownGracePeriod time.Duration
+}
+
+const (
+	// RemoveImagesAll removes all images used by services
+	RemoveImagesAll = "all"
+	// RemoveImagesLocal removes only images built for services without a custom tag
+	RemoveImagesLocal = "local"
+)
+
+// Tombstone records a project was intentionally torn down
+type Tombstone struct {
+	Project   string
+	RemovedAt time.Time
+}
+
+// AuditSink records destructive actions, for compliance environments needing a trail separate from progress output
+type AuditSink interface {
+	Record(event AuditEvent) error
+}
+
+const (
+	// AuditStop is the action of stopping a container
+	AuditStop = "stop"
+	// AuditRemove is the action of removing a container
+	AuditRemove = "remove"
+	// AuditNetworkRemove is the action of removing a network
+	AuditNetworkRemove = "network-remove"
+	// AuditVolumeRemove is the action of removing a volume
+	AuditVolumeRemove = "volume-remove"
+)
+
+// AuditEvent is a destructive action performed on a resource
+type AuditEvent struct {
+	Action string
+	// Resource is the ID or name of the resource, as sent to the engine
+	Resource  string
+	Project   string
+	Actor     string
+	Timestamp time.Time
+	// Error is set when the action failed
+	Error string
+}
+
+// ResourceOrder is the relative order of networks and volumes removal
+type ResourceOrder int
+
+const (
+	// NetworksFirst removes networks, then volumes
+	NetworksFirst ResourceOrder = iota
+	// VolumesFirst removes volumes, then networks, for volume plugins relying on project networks
+	VolumesFirst
+	// Concurrently removes networks and volumes at the same time, as they don't depend on each other once containers are gone
+	Concurrently
+)
+
+const (
+	// VolumesRemoval is the removal of volumes, and the data they hold
+	VolumesRemoval = "volumes"
+	// ImagesRemoval is the removal of images
+	ImagesRemoval = "images"
+)
+
+// DestructiveAction describes an irreversible teardown step
+type DestructiveAction struct {
+	// Kind is the kind of step, either VolumesRemoval or ImagesRemoval
+	Kind string
+	// Resources are the names of resources the step removes
+	Resources []string
+}
+
+// ServiceResolver returns the logical service a container belongs to, given its name and labels
+type ServiceResolver func(container string, labels map[string]string) string
+
+// ServiceTeardown decides how a service is torn down, given the service and the names of its containers
+type ServiceTeardown func(service types.ServiceConfig, containers []string) (TeardownDecision, error)
+
+// TeardownDecision adjusts the teardown of a single service. The zero value removes the service as configured by DownOptions
+type TeardownDecision struct {
+	// Skip keeps the service containers in place. Resources shared by the project are then kept as well
+	Skip bool
+	// ForceKill kills the service containers rather than draining and gracefully stopping them
+	ForceKill bool
+}
+
+// DrainOptions configure how containers are notified before they get stopped. Command and Signal are mutually exclusive
+type DrainOptions struct {
+	// Command is executed inside each container, typically to write a marker file the application watches
+	Command []string
+	// Signal is sent to each container main process
+	Signal string
+	// GracePeriod is the delay to let containers drain before they get stopped
+	GracePeriod time.Duration
+}
+
+// StopCheckOptions configure the command gating the stop of containers
+type StopCheckOptions struct {
+	// Command is executed inside each container, it must exit with status 0 for the container to be stopped
+	Command []string
+	// Interval is the delay between attempts, 1s by default
+	Interval time.Duration
+	// Timeout is the time for the command to succeed before the container is killed, 30s by default
+	Timeout time.Duration
+}
+
+// DownResult hold a summary of the resources removed by the Down API
+type DownResult struct {
+	Containers int
+	Networks   int
+	Volumes    int
+	// StaleFirewallRules are the host firewall rules left behind for removed networks
+	StaleFirewallRules []string
+	// CommittedImages are the image references containers were committed to, by container name
+	CommittedImages map[string]string
+	// ArchivedImages are the archival references project images were retagged to, by image name
+	ArchivedImages map[string]string
+	// PersistingVolumes are the anonymous volumes which still exist after containers they were attached to got removed
+	PersistingVolumes []string
+	// RemovedImages are the IDs or references of removed images
+	RemovedImages []string
+	// HeldPorts are the published host ports still bound after the teardown, as ip:port/protocol
+	HeldPorts []string
+	// ReclaimedSpace is the estimated disk space in bytes freed by removed volumes and images, when requested
+	ReclaimedSpace int64
+	// Stats are the last resource usage snapshots of running containers, by container name, when requested
+	Stats map[string]ContainerStats
+	// ServiceImages are the images of the project services, by service name, as recorded in the history
+	ServiceImages map[string]string
+	// StuckSubnets are the static subnets of removed networks still allocated by their IPAM driver, when verified
+	StuckSubnets []string
+}
+
+// ContainerStats is a snapshot of the resource usage of a container
+type ContainerStats struct {
+	// CPUPercent is the CPU usage, 100 being one CPU fully used
+	CPUPercent float64
+	// MemoryUsage and MemoryLimit are in bytes
+	MemoryUsage uint64
+	MemoryLimit uint64
+	// NetworkRx and NetworkTx are the bytes received and sent on all the container interfaces
+	NetworkRx uint64
+	NetworkTx uint64
+	// BlockRead and BlockWrite are the bytes read from and written to block devices
+	BlockRead  uint64
+	BlockWrite uint64
+}
+
+// RemovalStrategy defines how the Down API removes the resources of a project, given the function which removes
+// a single named resource
+type RemovalStrategy interface {
+	// RemoveContainers removes the containers of a service, service is empty for orphan containers
+	RemoveContainers(ctx context.Context, service string, containers []string, remove func(context.Context, string) error) error
+	// RemoveNetworks removes the project networks once all containers are removed
+	RemoveNetworks(ctx context.Context, networks []string, remove func(context.Context, string) error) error
+	// RemoveVolumes removes the project volumes once all containers are removed
+	RemoveVolumes(ctx context.Context, volumes []string, remove func(context.Context, string) error) error
+}
+
+// DownEstimate hold the number of resources the Down API would remove
+type DownEstimate struct {
+	Containers int
+	Networks   int
+	Volumes    int
+	// VolumesSize is the disk usage of volumes, in bytes
+	VolumesSize int64
 }
 
 // ConvertOptions group options of the Convert API
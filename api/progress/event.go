@@ -37,12 +37,49 @@ type Event struct {
 	Text       string
 	Status     EventStatus
 	StatusText string
+	// ResourceID is the daemon identifier of the resource the event is about, if any, as ID is a friendly name
+	ResourceID string
+	// Completion is the outcome of the operation, set on the last event an operation emits
+	Completion *Completion `json:",omitempty"`
 
 	startTime time.Time
 	endTime   time.Time
 	spinner   *spinner
 }
 
+// CompletionStatus is the machine-parseable outcome of an operation
+type CompletionStatus string
+
+const (
+	// Success means that the operation handled all resources
+	Success CompletionStatus = "Success"
+	// PartialSuccess means that the operation succeeded, but some non-fatal resources were skipped or failed
+	PartialSuccess CompletionStatus = "PartialSuccess"
+	// Failed means that the operation failed
+	Failed CompletionStatus = "Failed"
+)
+
+// Completion is the outcome of an operation
+type Completion struct {
+	Status CompletionStatus
+	// Errors describe the failures which led to the status, if any
+	Errors []string `json:",omitempty"`
+}
+
+// CompletionEvent creates the last Event of an operation, reporting its outcome
+func CompletionEvent(ID string, completion Completion) Event {
+	e := NewEvent(ID, Done, "Completed")
+	switch completion.Status {
+	case PartialSuccess:
+		e.StatusText = "Completed with errors"
+	case Failed:
+		e.Status = Error
+		e.StatusText = "Failed"
+	}
+	e.Completion = &completion
+	return e
+}
+
 // ErrorMessageEvent creates a new Error Event with message
 func ErrorMessageEvent(ID string, msg string) Event {
 	return NewEvent(ID, Error, msg)
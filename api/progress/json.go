@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// jsonWriter prints events as JSON lines, the way an EventRecorder records them
+type jsonWriter struct {
+	mtx     sync.Mutex
+	encoder *json.Encoder
+	done    chan bool
+}
+
+func newJSONWriter(out io.Writer) Writer {
+	return &jsonWriter{
+		encoder: json.NewEncoder(out),
+		done:    make(chan bool),
+	}
+}
+
+func (j *jsonWriter) Start(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-j.done:
+		return nil
+	}
+}
+
+func (j *jsonWriter) Event(e Event) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	_ = j.encoder.Encode(e)
+}
+
+func (j *jsonWriter) Stop() {
+	j.done <- true
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type maskingWriter struct {
+	Writer
+	mtx sync.Mutex
+	// ids are masked event IDs by event ID
+	ids map[string]string
+	// names are masked names by resource name, to redact texts
+	names map[string]string
+	// counts are the number of masked resources by kind
+	counts map[string]int
+}
+
+// NewMaskingWriter returns a writer redacting resource names from events before they reach w, so that progress
+// output can go to shared logs. A resource event ID like `Container "name"` becomes "Container 1", numbered in the
+// order resources of this kind show up, known names are redacted from event texts and resource IDs are dropped
+func NewMaskingWriter(w Writer) Writer {
+	return &maskingWriter{
+		Writer: w,
+		ids:    map[string]string{},
+		names:  map[string]string{},
+		counts: map[string]int{},
+	}
+}
+
+func (m *maskingWriter) Event(e Event) {
+	m.mtx.Lock()
+	e.ID = m.mask(e.ID)
+	// volume IDs are their names
+	e.ResourceID = ""
+	e.ParentID = m.mask(e.ParentID)
+	e.Text = m.redact(e.Text)
+	e.StatusText = m.redact(e.StatusText)
+	if e.Completion != nil {
+		completion := Completion{Status: e.Completion.Status}
+		for _, msg := range e.Completion.Errors {
+			completion.Errors = append(completion.Errors, m.redact(msg))
+		}
+		e.Completion = &completion
+	}
+	m.mtx.Unlock()
+	m.Writer.Event(e)
+}
+
+// unquotedKinds are the resource kinds which event IDs name without quotes, e.g. `Container name`
+var unquotedKinds = []string{"Container", "Service", "Image"}
+
+// mask returns the masked form of a resource event ID, `Kind "name"` or the unquoted ID of a known resource kind. IDs
+// which don't name a resource, like "Dangling images", are kept
+func (m *maskingWriter) mask(id string) string {
+	if masked, ok := m.ids[id]; ok {
+		return masked
+	}
+	kind, name, ok := resourceID(id)
+	if !ok {
+		return id
+	}
+	m.counts[kind]++
+	masked := fmt.Sprintf("%s %d", kind, m.counts[kind])
+	m.ids[id] = masked
+	if _, ok := m.names[name]; !ok {
+		m.names[name] = masked
+	}
+	return masked
+}
+
+// resourceID splits a resource event ID into the resource kind and name
+func resourceID(id string) (string, string, bool) {
+	parts := strings.SplitN(id, " ", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", false
+	}
+	kind, name := parts[0], parts[1]
+	if len(name) > 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return kind, name[1 : len(name)-1], true
+	}
+	for _, k := range unquotedKinds {
+		if kind == k && !strings.Contains(name, " ") {
+			return kind, name, true
+		}
+	}
+	return "", "", false
+}
+
+// redact replaces known resource names in a text, longest first so that names which contain others are replaced whole
+func (m *maskingWriter) redact(text string) string {
+	if text == "" {
+		return text
+	}
+	var names []string
+	for name := range m.names {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	for _, name := range names {
+		text = replaceName(text, name, m.names[name])
+	}
+	return text
+}
+
+// replaceName replaces the occurrences of a name which aren't part of a longer name
+func replaceName(text string, name string, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, name)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(name)
+		b.WriteString(text[:i])
+		if (i > 0 && isNameByte(text[i-1])) || (end < len(text) && isNameByte(text[end])) {
+			b.WriteString(name)
+		} else {
+			b.WriteString(replacement)
+		}
+		text = text[end:]
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
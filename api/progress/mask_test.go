@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+type recordingWriter struct {
+	events []Event
+}
+
+func (r *recordingWriter) Start(context.Context) error {
+	return nil
+}
+
+func (r *recordingWriter) Stop() {
+}
+
+func (r *recordingWriter) Event(e Event) {
+	r.events = append(r.events, e)
+}
+
+func TestMaskingWriter(t *testing.T) {
+	out := &recordingWriter{}
+	w := NewMaskingWriter(out)
+
+	w.Event(RemovingEvent("Container p_web_1"))
+	w.Event(RemovingEvent("Container p_db_1"))
+	w.Event(RemovedEvent("Container p_web_1"))
+	w.Event(NewEvent(`Network "p_default"`, Working, "Disconnecting leftover endpoint p_db_1"))
+	w.Event(NewEvent("Deadline", Working, "teardown deadline in 1s"))
+
+	var ids, texts []string
+	for _, e := range out.events {
+		ids = append(ids, e.ID)
+		texts = append(texts, e.StatusText)
+	}
+	assert.DeepEqual(t, ids, []string{"Container 1", "Container 2", "Container 1", "Network 1", "Deadline"})
+	assert.DeepEqual(t, texts, []string{"Removing", "Removing", "Removed", "Disconnecting leftover endpoint Container 2", "teardown deadline in 1s"})
+}
+
+func TestMaskingWriterLongestNameFirst(t *testing.T) {
+	out := &recordingWriter{}
+	w := NewMaskingWriter(out)
+
+	w.Event(NewEvent("Service web", Done, ""))
+	w.Event(NewEvent("Container p_web_1", Done, ""))
+	w.Event(NewEvent("Image web", Error, "image web is used by p_web_1"))
+
+	assert.Equal(t, out.events[2].ID, "Image 1")
+	assert.Equal(t, out.events[2].StatusText, "image Service 1 is used by Container 1")
+}
+
+func TestMaskingWriterDropsResourceID(t *testing.T) {
+	out := &recordingWriter{}
+	w := NewMaskingWriter(out)
+
+	e := RemovedEvent(`Volume "p_data"`)
+	e.ResourceID = "p_data"
+	w.Event(e)
+
+	assert.Equal(t, out.events[0].ID, "Volume 1")
+	assert.Equal(t, out.events[0].ResourceID, "")
+}
+
+func TestMaskingWriterRedactsCompletionErrors(t *testing.T) {
+	out := &recordingWriter{}
+	w := NewMaskingWriter(out)
+
+	completion := Completion{Status: PartialSuccess, Errors: []string{"failed to remove p_web_1"}}
+	w.Event(RemovingEvent("Container p_web_1"))
+	w.Event(CompletionEvent("Down", completion))
+
+	assert.DeepEqual(t, out.events[1].Completion, &Completion{Status: PartialSuccess, Errors: []string{"failed to remove Container 1"}})
+	// the event sent is left untouched
+	assert.DeepEqual(t, completion.Errors, []string{"failed to remove p_web_1"})
+}
+
+func TestMaskingWriterKeepsNonResourceIDs(t *testing.T) {
+	out := &recordingWriter{}
+	w := NewMaskingWriter(out)
+
+	w.Event(NewEvent("Dangling images", Done, "2 images pruned"))
+	w.Event(NewEvent("Service web", Done, ""))
+	w.Event(NewEvent(`Volume "p_data"`, Error, "volume p_data is used by webapp, not by web"))
+
+	assert.Equal(t, out.events[0].ID, "Dangling images")
+	assert.Equal(t, out.events[0].StatusText, "2 images pruned")
+	assert.Equal(t, out.events[2].StatusText, "volume Volume 1 is used by webapp, not by Service 1")
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// EventRecorder is a writer recording events as JSON lines before forwarding them, so that a session can be replayed,
+// typically to reproduce a UI issue from a user report
+type EventRecorder struct {
+	Writer
+	mtx     sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewEventRecorder returns a writer recording events to out, then forwarding them to w
+func NewEventRecorder(w Writer, out io.Writer) *EventRecorder {
+	return &EventRecorder{
+		Writer:  w,
+		encoder: json.NewEncoder(out),
+	}
+}
+
+// Event records then forwards an event. Once recording failed, events are only forwarded
+func (r *EventRecorder) Event(e Event) {
+	r.mtx.Lock()
+	if r.err == nil {
+		r.err = r.encoder.Encode(e)
+	}
+	r.mtx.Unlock()
+	r.Writer.Event(e)
+}
+
+// Err returns the error which stopped the recording, if any
+func (r *EventRecorder) Err() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.err
+}
+
+// Replay sends events recorded by an EventRecorder to w, in the order they were recorded
+func Replay(in io.Reader, w Writer) error {
+	decoder := json.NewDecoder(in)
+	for {
+		var e Event
+		err := decoder.Decode(&e)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w.Event(e)
+	}
+}
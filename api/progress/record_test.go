@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/v3/assert"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	events := []Event{
+		StoppingEvent("Container p_web_1"),
+		{ID: "Container p_web_1", ParentID: "Service web", Status: Working, StatusText: "Removing checkpoint nightly", ResourceID: "3f9c0d2b7a61"},
+		RemovedEvent("Container p_web_1"),
+		ErrorMessageEvent(`Network "p_default"`, "Error while Removing"),
+		NewEvent("Retry", Done, "All resources removed"),
+		CompletionEvent("Down", Completion{Status: PartialSuccess, Errors: []string{`Network "p_default": Error while Removing`}}),
+	}
+
+	var recording bytes.Buffer
+	live := &recordingWriter{}
+	w := NewEventRecorder(live, &recording)
+	for _, e := range events {
+		w.Event(e)
+	}
+	assert.NilError(t, w.Err())
+	assert.Equal(t, len(live.events), len(events))
+	assert.Equal(t, bytes.Count(recording.Bytes(), []byte("\n")), len(events))
+
+	replayed := &recordingWriter{}
+	err := Replay(&recording, replayed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, replayed.events, events, cmpopts.IgnoreUnexported(Event{}))
+}
+
+type failingOutput struct{}
+
+func (failingOutput) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestRecordFailureKeepsForwarding(t *testing.T) {
+	live := &recordingWriter{}
+	w := NewEventRecorder(live, failingOutput{})
+	w.Event(RemovingEvent("Container p_web_1"))
+	w.Event(RemovedEvent("Container p_web_1"))
+
+	assert.Error(t, w.Err(), "no space left on device")
+	assert.Equal(t, len(live.events), 2)
+}
+
+func TestReplayInvalidRecording(t *testing.T) {
+	replayed := &recordingWriter{}
+	err := Replay(bytes.NewBufferString(`{"ID":"Container p_web_1","Status":1}`+"\n{"), replayed)
+	assert.ErrorContains(t, err, "unexpected EOF")
+	assert.Equal(t, len(replayed.events), 1)
+}
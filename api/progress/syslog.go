@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// syslogSink is the subset of a syslog writer used to forward events
+type syslogSink interface {
+	Info(m string) error
+	Err(m string) error
+}
+
+type syslogWriter struct {
+	sink     syslogSink
+	fallback Writer
+	failed   bool
+	mtx      sync.Mutex
+	done     chan bool
+}
+
+// NewSyslogWriter returns a writer forwarding events to syslog, so that headless servers collect them with other
+// logs. network and raddr select a remote syslog server, or the local one when empty. As progress is best effort,
+// events go to fallback, if set, when syslog is unavailable
+func NewSyslogWriter(network, raddr, tag string, fallback Writer) Writer {
+	if fallback == nil {
+		fallback = &noopWriter{}
+	}
+	sink, err := dialSyslog(network, raddr, tag)
+	if err != nil {
+		return fallback
+	}
+	return newSyslogWriter(sink, fallback)
+}
+
+func newSyslogWriter(sink syslogSink, fallback Writer) *syslogWriter {
+	return &syslogWriter{
+		sink:     sink,
+		fallback: fallback,
+		done:     make(chan bool),
+	}
+}
+
+func (s *syslogWriter) Start(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return nil
+	}
+}
+
+func (s *syslogWriter) Event(e Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.failed {
+		s.fallback.Event(e)
+		return
+	}
+	m := fmt.Sprintln(e.ID, e.Text, e.StatusText)
+	var err error
+	if e.Status == Error {
+		err = s.sink.Err(m)
+	} else {
+		err = s.sink.Info(m)
+	}
+	if err != nil {
+		s.failed = true
+		s.fallback.Event(e)
+	}
+}
+
+func (s *syslogWriter) Stop() {
+	s.done <- true
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+type fakeSyslog struct {
+	messages []string
+	err      error
+}
+
+func (f *fakeSyslog) Info(m string) error {
+	f.messages = append(f.messages, "info: "+m)
+	return f.err
+}
+
+func (f *fakeSyslog) Err(m string) error {
+	f.messages = append(f.messages, "err: "+m)
+	return f.err
+}
+
+func TestSyslogWriter(t *testing.T) {
+	sink := &fakeSyslog{}
+	w := newSyslogWriter(sink, &noopWriter{})
+	w.Event(RemovingEvent("Container p_web_1"))
+	w.Event(RemovedEvent("Container p_web_1"))
+	w.Event(ErrorMessageEvent("Container p_db_1", "Error while Removing"))
+
+	assert.DeepEqual(t, sink.messages, []string{
+		"info: Container p_web_1  Removing\n",
+		"info: Container p_web_1  Removed\n",
+		"err: Container p_db_1  Error while Removing\n",
+	})
+}
+
+func TestSyslogWriterFallback(t *testing.T) {
+	sink := &fakeSyslog{err: errors.New("connection refused")}
+	w := newSyslogWriter(sink, &plainWriter{})
+	out := captureStdout(t, func() {
+		w.Event(RemovingEvent("Container p_web_1"))
+		w.Event(RemovedEvent("Container p_web_1"))
+	})
+
+	assert.Equal(t, len(sink.messages), 1)
+	assert.Equal(t, out, "Container p_web_1  Removing\nContainer p_web_1  Removed\n")
+}
+
+func TestSyslogWriterUnavailable(t *testing.T) {
+	fallback := &noopWriter{}
+	w := NewSyslogWriter("tcp", "localhost:0", "compose", fallback)
+	assert.Equal(t, w, fallback)
+}
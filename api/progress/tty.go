@@ -118,7 +118,7 @@ func (w *ttyWriter) print() {
 	var statusPadding int
 	for _, v := range w.eventIDs {
 		event := w.events[v]
-		l := len(fmt.Sprintf("%s %s", event.ID, event.Text))
+		l := len(fmt.Sprintf("%s %s", truncateID(event.ID, maxIDWidth(terminalWidth)), event.Text))
 		if statusPadding < l {
 			statusPadding = l
 		}
@@ -159,7 +159,8 @@ func lineText(event Event, pad string, terminalWidth, statusPadding int, color b
 
 	elapsed := endTime.Sub(event.startTime).Seconds()
 
-	textLen := len(fmt.Sprintf("%s %s", event.ID, event.Text))
+	id := truncateID(event.ID, maxIDWidth(terminalWidth))
+	textLen := len(fmt.Sprintf("%s %s", id, event.Text))
 	padding := statusPadding - textLen
 	if padding < 0 {
 		padding = 0
@@ -175,7 +176,7 @@ func lineText(event Event, pad string, terminalWidth, statusPadding int, color b
 	text := fmt.Sprintf("%s %s %s %s%s %s",
 		pad,
 		event.spinner.String(),
-		event.ID,
+		id,
 		event.Text,
 		strings.Repeat(" ", padding),
 		status,
@@ -197,6 +198,26 @@ func lineText(event Event, pad string, terminalWidth, statusPadding int, color b
 	return o
 }
 
+// maxIDWidth is the maximum width of an event ID, so that long names don't wrap and corrupt the animated output
+func maxIDWidth(terminalWidth int) int {
+	return terminalWidth / 2
+}
+
+// truncateID shortens an ID longer than max with an ellipsis in the middle, preserving the tail which
+// holds the container index
+func truncateID(id string, max int) string {
+	const ellipsis = "..."
+	if max <= 0 || len(id) <= max {
+		return id
+	}
+	if max <= len(ellipsis) {
+		return id[len(id)-max:]
+	}
+	keep := max - len(ellipsis)
+	head := keep / 2
+	return id[:head] + ellipsis + id[len(id)-(keep-head):]
+}
+
 func numDone(events map[string]Event) int {
 	i := 0
 	for _, e := range events {
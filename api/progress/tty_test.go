@@ -84,3 +84,28 @@ func TestErrorEvent(t *testing.T) {
 	assert.Assert(t, ok)
 	assert.Assert(t, event.endTime.After(time.Now().Add(-10*time.Second)))
 }
+
+func TestLineTextLongID(t *testing.T) {
+	now := time.Now()
+	ev := Event{
+		ID:         "Container a_very_long_project_name_with_a_very_long_service_name_12",
+		Status:     Done,
+		StatusText: "Removed",
+		endTime:    now,
+		startTime:  now,
+		spinner: &spinner{
+			chars: []string{"."},
+		},
+	}
+
+	out := lineText(ev, "", 60, 0, false)
+	assert.Equal(t, out, " . Container a_v...ervice_name_12  Removed             0.0s\n")
+	assert.Equal(t, len(out), 60)
+}
+
+func TestTruncateID(t *testing.T) {
+	assert.Equal(t, truncateID("Container web_1", 40), "Container web_1")
+	assert.Equal(t, truncateID("Container web_1", 0), "Container web_1")
+	assert.Equal(t, truncateID("Container project_service_1", 15), "Contai...vice_1")
+	assert.Equal(t, truncateID("Container project_service_1", 3), "e_1")
+}
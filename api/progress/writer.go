@@ -18,11 +18,15 @@ package progress
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/containerd/console"
 	"github.com/moby/term"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -79,28 +83,101 @@ func Run(ctx context.Context, pf progressFunc) (string, error) {
 	return result, err
 }
 
-// NewWriter returns a new multi-progress writer
-func NewWriter(out console.File) (Writer, error) {
-	_, isTerminal := term.GetFdInfo(out)
+const (
+	// ModeAuto selects a tty writer when the output is a terminal, a plain one otherwise
+	ModeAuto = "auto"
+	// ModeTTY renders events as lines updated in place
+	ModeTTY = "tty"
+	// ModePlain prints a line per event
+	ModePlain = "plain"
+	// ModeJSON prints events as JSON lines, for programs to parse
+	ModeJSON = "json"
+	// ModeQuiet prints nothing
+	ModeQuiet = "quiet"
+)
+
+// progressEnvVar is the environment variable selecting the progress mode, when not set explicitly
+const progressEnvVar = "COMPOSE_PROGRESS"
+
+// mode is the kind of writer NewWriter returns, see SetMode
+var mode = ModeAuto
+
+var modes = []string{ModeAuto, ModeTTY, ModePlain, ModeJSON, ModeQuiet}
+
+// SetMode sets the kind of writer NewWriter returns. It is meant to be called once, by the CLI parsing its flags.
+// When set to ModeAuto, the COMPOSE_PROGRESS environment variable selects it, then whether the output is a terminal
+func SetMode(m string) error {
+	if !isMode(m) {
+		return fmt.Errorf("unsupported progress mode %q, expected one of %s", m, strings.Join(modes, ", "))
+	}
+	mode = m
+	return nil
+}
 
-	if isTerminal {
-		con, err := console.ConsoleFromFile(out)
-		if err != nil {
-			return nil, err
+func isMode(m string) bool {
+	for _, known := range modes {
+		if m == known {
+			return true
 		}
+	}
+	return false
+}
 
-		return &ttyWriter{
-			out:      con,
-			eventIDs: []string{},
-			events:   map[string]Event{},
-			repeated: false,
-			done:     make(chan bool),
-			mtx:      &sync.RWMutex{},
-		}, nil
+// selectedMode returns the progress mode, set explicitly or from the environment. An unsupported mode in the
+// environment falls back to ModeAuto, not to break commands over a cosmetic setting
+func selectedMode() string {
+	if mode != ModeAuto {
+		return mode
+	}
+	env := os.Getenv(progressEnvVar)
+	if env == "" {
+		return ModeAuto
 	}
+	if !isMode(env) {
+		logrus.Warnf("unsupported %s value %q, expected one of %s: using %s", progressEnvVar, env, strings.Join(modes, ", "), ModeAuto)
+		return ModeAuto
+	}
+	return env
+}
 
+// NewWriter returns a new multi-progress writer, of the kind the progress mode selects
+func NewWriter(out console.File) (Writer, error) {
+	switch selectedMode() {
+	case ModeTTY:
+		return newTTYWriter(out)
+	case ModePlain:
+		return newPlainWriter(out), nil
+	case ModeJSON:
+		return newJSONWriter(out), nil
+	case ModeQuiet:
+		return &noopWriter{}, nil
+	}
+
+	if _, isTerminal := term.GetFdInfo(out); isTerminal {
+		return newTTYWriter(out)
+	}
+	return newPlainWriter(out), nil
+}
+
+func newTTYWriter(out console.File) (Writer, error) {
+	con, err := console.ConsoleFromFile(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ttyWriter{
+		out:      con,
+		eventIDs: []string{},
+		events:   map[string]Event{},
+		repeated: false,
+		done:     make(chan bool),
+		mtx:      &sync.RWMutex{},
+	}, nil
+}
+
+func newPlainWriter(out io.Writer) Writer {
 	return &plainWriter{
 		out:  out,
 		done: make(chan bool),
-	}, nil
+	}
 }
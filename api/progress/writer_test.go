@@ -17,7 +17,12 @@
 package progress
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -29,3 +34,108 @@ func TestNoopWriter(t *testing.T) {
 
 	assert.Equal(t, writer, &noopWriter{})
 }
+
+// captureStdout returns what f prints to the standard output, where the plain writer prints events
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	assert.NilError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+	}()
+	f()
+	assert.NilError(t, w.Close())
+	out, err := ioutil.ReadAll(r)
+	assert.NilError(t, err)
+	return string(out)
+}
+
+func TestPlainWriterLongID(t *testing.T) {
+	w := &plainWriter{}
+	id := "Container a_very_long_project_name_with_a_very_long_service_name_12"
+	out := captureStdout(t, func() {
+		w.Event(RemovedEvent(id))
+	})
+	assert.Equal(t, out, id+"  Removed\n")
+}
+
+func TestNewWriterMode(t *testing.T) {
+	out, err := ioutil.TempFile("", "progress")
+	assert.NilError(t, err)
+	defer os.Remove(out.Name()) // nolint:errcheck
+	defer out.Close()           // nolint:errcheck
+	defer func(m string) { mode = m }(mode)
+	defer os.Unsetenv(progressEnvVar) // nolint:errcheck
+
+	cases := []struct {
+		mode     string
+		env      string
+		expected Writer
+	}{
+		// output is not a terminal
+		{mode: ModeAuto, expected: &plainWriter{}},
+		{mode: ModeAuto, env: ModeAuto, expected: &plainWriter{}},
+		{mode: ModeAuto, env: ModePlain, expected: &plainWriter{}},
+		{mode: ModeAuto, env: ModeJSON, expected: &jsonWriter{}},
+		{mode: ModeAuto, env: ModeQuiet, expected: &noopWriter{}},
+		// explicit mode wins over the environment
+		{mode: ModeJSON, env: ModePlain, expected: &jsonWriter{}},
+		{mode: ModeQuiet, expected: &noopWriter{}},
+		{mode: ModePlain, env: ModeQuiet, expected: &plainWriter{}},
+	}
+	for _, c := range cases {
+		assert.NilError(t, SetMode(c.mode))
+		os.Setenv(progressEnvVar, c.env) // nolint:errcheck
+		w, err := NewWriter(out)
+		assert.NilError(t, err, "mode %q, env %q", c.mode, c.env)
+		assert.Equal(t, fmt.Sprintf("%T", w), fmt.Sprintf("%T", c.expected), "mode %q, env %q", c.mode, c.env)
+	}
+}
+
+func TestNewWriterTTYModeNotATerminal(t *testing.T) {
+	out, err := ioutil.TempFile("", "progress")
+	assert.NilError(t, err)
+	defer os.Remove(out.Name()) // nolint:errcheck
+	defer out.Close()           // nolint:errcheck
+	defer func(m string) { mode = m }(mode)
+
+	assert.NilError(t, SetMode(ModeTTY))
+	_, err = NewWriter(out)
+	assert.Assert(t, err != nil)
+}
+
+func TestNewWriterInvalidMode(t *testing.T) {
+	out, err := ioutil.TempFile("", "progress")
+	assert.NilError(t, err)
+	defer os.Remove(out.Name()) // nolint:errcheck
+	defer out.Close()           // nolint:errcheck
+	defer func(m string) { mode = m }(mode)
+	defer os.Unsetenv(progressEnvVar) // nolint:errcheck
+
+	// falls back to auto, output is not a terminal
+	os.Setenv(progressEnvVar, "fancy") // nolint:errcheck
+	w, err := NewWriter(out)
+	assert.NilError(t, err)
+	assert.Equal(t, fmt.Sprintf("%T", w), fmt.Sprintf("%T", &plainWriter{}))
+
+	assert.Error(t, SetMode("verbose"), `unsupported progress mode "verbose", expected one of auto, tty, plain, json, quiet`)
+	assert.Equal(t, mode, ModeAuto)
+}
+
+func TestJSONWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := newJSONWriter(out)
+	w.Event(RemovingEvent("Container p_web_1"))
+	w.Event(RemovedEvent("Container p_web_1"))
+
+	decoder := json.NewDecoder(out)
+	var e Event
+	assert.NilError(t, decoder.Decode(&e))
+	assert.Equal(t, e.StatusText, "Removing")
+	assert.NilError(t, decoder.Decode(&e))
+	assert.Equal(t, e.ID, "Container p_web_1")
+	assert.Equal(t, e.Status, Done)
+	assert.Equal(t, e.StatusText, "Removed")
+	assert.Assert(t, !decoder.More())
+}
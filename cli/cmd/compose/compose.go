@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/docker/compose-cli/api/context/store"
+	"github.com/docker/compose-cli/api/progress"
 )
 
 type projectOptions struct {
@@ -79,6 +80,7 @@ func (o *projectOptions) toProjectOptions() (*cli.ProjectOptions, error) {
 // Command returns the compose command with its child commands
 func Command(contextType string) *cobra.Command {
 	opts := projectOptions{}
+	var progressMode string
 	command := &cobra.Command{
 		Short: "Docker Compose",
 		Use:   "compose",
@@ -86,7 +88,7 @@ func Command(contextType string) *cobra.Command {
 			if contextType == store.DefaultContextType || contextType == store.LocalContextType {
 				fmt.Println("The new 'docker compose' command is currently experimental. To provide feedback or request new features please open issues at https://github.com/docker/compose-cli")
 			}
-			return nil
+			return progress.SetMode(progressMode)
 		},
 	}
 
@@ -109,6 +111,7 @@ func Command(contextType string) *cobra.Command {
 	}
 	command.Flags().SetInterspersed(false)
 	opts.addProjectFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVar(&progressMode, "progress", progress.ModeAuto, "Set type of progress output (auto, tty, plain, json, quiet)")
 	return command
 }
 
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/errdefs"
 
 	"github.com/docker/compose-cli/api/progress"
 )
@@ -52,6 +53,14 @@ func (b *ecsAPIService) Down(ctx context.Context, projectName string, options co
 	return b.WaitStackCompletion(ctx, projectName, stackDelete, previousEvents...)
 }
 
+func (b *ecsAPIService) EstimateDown(ctx context.Context, projectName string, options compose.DownOptions) (*compose.DownEstimate, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+func (b *ecsAPIService) Tombstone(ctx context.Context, projectName string) (*compose.Tombstone, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
 func (b *ecsAPIService) previousStackEvents(ctx context.Context, project string) ([]string, error) {
 	events, err := b.aws.DescribeStackEvents(ctx, project)
 	if err != nil {
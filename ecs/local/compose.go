@@ -158,6 +158,15 @@ func (e ecsLocalSimulation) Down(ctx context.Context, projectName string, option
 	return e.compose.Down(ctx, projectName, options)
 }
 
+func (e ecsLocalSimulation) EstimateDown(ctx context.Context, projectName string, options compose.DownOptions) (*compose.DownEstimate, error) {
+	options.RemoveOrphans = true
+	return e.compose.EstimateDown(ctx, projectName, options)
+}
+
+func (e ecsLocalSimulation) Tombstone(ctx context.Context, projectName string) (*compose.Tombstone, error) {
+	return e.compose.Tombstone(ctx, projectName)
+}
+
 func (e ecsLocalSimulation) Logs(ctx context.Context, projectName string, consumer compose.LogConsumer, options compose.LogOptions) error {
 	return e.compose.Logs(ctx, projectName, consumer, options)
 }
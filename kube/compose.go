@@ -55,6 +55,16 @@ func (s *composeService) Down(ctx context.Context, projectName string, options c
 	return s.sdk.Uninstall(projectName)
 }
 
+// EstimateDown computes the number of resources a `compose down` would remove
+func (s *composeService) EstimateDown(ctx context.Context, projectName string, options compose.DownOptions) (*compose.DownEstimate, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
+// Tombstone returns the record of the project intentional teardown
+func (s *composeService) Tombstone(ctx context.Context, projectName string) (*compose.Tombstone, error) {
+	return nil, errdefs.ErrNotImplemented
+}
+
 // List executes the equivalent to a `docker stack ls`
 func (s *composeService) List(ctx context.Context) ([]compose.Stack, error) {
 	return s.sdk.List()
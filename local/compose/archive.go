@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/docker/distribution/reference"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// archiveReference is the image reference an image is retagged to under the archival prefix
+func archiveReference(prefix string, image string, t time.Time) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimPrefix(reference.Path(named), "library/")
+	return fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(prefix, "/"), path, t.UTC().Format(snapshotTimeFormat)), nil
+}
+
+// archiveImages retags the images built for the project under an archival prefix, then removes their project tag,
+// so that active tags are cleaned up but images aren't lost
+func (s *composeService) archiveImages(ctx context.Context, project *types.Project, prefix string, summary *downSummary) error {
+	w := progress.ContextWriter(ctx)
+	now := time.Now()
+	for _, service := range project.Services {
+		if service.Build == nil {
+			continue
+		}
+		image := getImageName(service, project.Name)
+		archive, err := archiveReference(prefix, image, now)
+		if err != nil {
+			return errors.Wrapf(err, "invalid archival reference for image %s", image)
+		}
+		eventName := fmt.Sprintf("Image %s", image)
+		w.Event(progress.NewEvent(eventName, progress.Working, "Archiving"))
+		err = s.apiClient.ImageTag(ctx, image, archive)
+		if errdefs.IsNotFound(err) {
+			w.Event(progress.NewEvent(eventName, progress.Done, "Not found"))
+			continue
+		}
+		if err != nil {
+			w.Event(progress.ErrorEvent(eventName))
+			return err
+		}
+		_, err = s.apiClient.ImageRemove(ctx, image, moby.ImageRemoveOptions{})
+		if err != nil {
+			w.Event(progress.ErrorEvent(eventName))
+			return err
+		}
+		w.Event(progress.NewEvent(eventName, progress.Done, "Archived as "+archive))
+		summary.imageArchived(image, archive)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestArchiveReference(t *testing.T) {
+	now := time.Date(2021, 1, 20, 10, 30, 0, 0, time.UTC)
+	ref, err := archiveReference("archive", "p_web", now)
+	assert.NilError(t, err)
+	assert.Equal(t, ref, "archive/p_web:20210120103000")
+
+	ref, err = archiveReference("archive/", "registry.example.com/team/web:1.0", now)
+	assert.NilError(t, err)
+	assert.Equal(t, ref, "archive/team/web:20210120103000")
+}
+
+func TestDownArchiveImages(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:web", RepoTags: []string{"p_web:latest"}},
+		{ID: "sha256:db", RepoTags: []string{"postgres:latest"}},
+	}
+	project := testProject("p", "web", "db", "worker")
+	project.Services[0].Build = &types.BuildConfig{Context: "."}
+	project.Services[2].Build = &types.BuildConfig{Context: "."}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, ArchiveImagesPrefix: "archive", Result: &result})
+	assert.NilError(t, err)
+	assert.Assert(t, api.callIndex("ImageTag", "p_web") < api.callIndex("ImageRemove", "p_web"), "image must be retagged before it is untagged")
+	assert.Equal(t, len(api.images), 2)
+	assert.Equal(t, len(api.images[0].RepoTags), 1)
+	assert.Assert(t, strings.HasPrefix(api.images[0].RepoTags[0], "archive/p_web:"))
+	assert.DeepEqual(t, result.ArchivedImages, map[string]string{"p_web": api.images[0].RepoTags[0]})
+	// image not built by the project is kept as is, missing built image is ignored
+	assert.DeepEqual(t, api.images[1].RepoTags, []string{"postgres:latest"})
+	assert.Equal(t, len(api.callsTo("ImageRemove")), 1)
+}
@@ -0,0 +1,105 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// auditLog buffers audit events until the teardown completes, it is safe for concurrent use
+type auditLog struct {
+	mtx     sync.Mutex
+	project string
+	actor   string
+	events  []compose.AuditEvent
+}
+
+func (l *auditLog) record(action string, resource string, err error) {
+	event := compose.AuditEvent{
+		Action:    action,
+		Resource:  resource,
+		Project:   l.project,
+		Actor:     l.actor,
+		Timestamp: time.Now().UTC(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.events = append(l.events, event)
+}
+
+// flush records buffered events to the sink, in the order actions were performed. All events are attempted so the
+// trail is as complete as possible
+func (l *auditLog) flush(sink compose.AuditSink) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	failed := 0
+	var first error
+	for _, event := range l.events {
+		if err := sink.Record(event); err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	l.events = nil
+	if failed > 0 {
+		return errors.Wrapf(first, "failed to record %d audit event(s)", failed)
+	}
+	return nil
+}
+
+// auditedClient records destructive daemon calls to an audit log
+type auditedClient struct {
+	client.APIClient
+	log *auditLog
+}
+
+func (c auditedClient) ContainerStop(ctx context.Context, id string, timeout *time.Duration) error {
+	err := c.APIClient.ContainerStop(ctx, id, timeout)
+	c.log.record(compose.AuditStop, id, err)
+	return err
+}
+
+func (c auditedClient) ContainerRemove(ctx context.Context, id string, options moby.ContainerRemoveOptions) error {
+	err := c.APIClient.ContainerRemove(ctx, id, options)
+	c.log.record(compose.AuditRemove, id, err)
+	return err
+}
+
+func (c auditedClient) NetworkRemove(ctx context.Context, id string) error {
+	err := c.APIClient.NetworkRemove(ctx, id)
+	c.log.record(compose.AuditNetworkRemove, id, err)
+	return err
+}
+
+func (c auditedClient) VolumeRemove(ctx context.Context, id string, force bool) error {
+	err := c.APIClient.VolumeRemove(ctx, id, force)
+	c.log.record(compose.AuditVolumeRemove, id, err)
+	return err
+}
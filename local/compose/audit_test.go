@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// recordingSink is an AuditSink collecting all events
+type recordingSink struct {
+	mtx    sync.Mutex
+	events []compose.AuditEvent
+	err    error
+}
+
+func (r *recordingSink) Record(event compose.AuditEvent) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingSink) actions() []string {
+	var actions []string
+	for _, e := range r.events {
+		actions = append(actions, e.Action+" "+e.Resource)
+	}
+	return actions
+}
+
+func TestDownAuditSink(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	sink := &recordingSink{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true, AuditSink: sink})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sink.actions(), []string{
+		"stop p_web_1",
+		"remove p_web_1",
+		"network-remove p_default",
+		"volume-remove p_data",
+	})
+	for _, e := range sink.events {
+		assert.Equal(t, e.Project, "p")
+		assert.Assert(t, e.Actor != "")
+		assert.Assert(t, !e.Timestamp.IsZero())
+		assert.Equal(t, e.Error, "")
+	}
+}
+
+func TestDownAuditSinkRecordsFailures(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device busy")
+	tested := composeService{apiClient: api}
+
+	sink := &recordingSink{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), AuditSink: sink})
+	assert.ErrorContains(t, err, "device busy")
+	assert.DeepEqual(t, sink.actions(), []string{"stop p_web_1", "remove p_web_1"})
+	assert.Equal(t, sink.events[1].Error, "device busy")
+}
+
+func TestDownAuditSinkFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	sink := &recordingSink{err: fmt.Errorf("audit server unreachable")}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), AuditSink: sink})
+	assert.ErrorContains(t, err, "failed to record 2 audit event(s): audit server unreachable")
+	// teardown itself completed
+	assert.Equal(t, len(api.containers), 0)
+}
@@ -37,7 +37,7 @@ func (s *composeService) Build(ctx context.Context, project *types.Project) erro
 	for _, service := range project.Services {
 		if service.Build != nil {
 			imageName := getImageName(service, project.Name)
-			opts[imageName] = s.toBuildOptions(service, project.WorkingDir, imageName)
+			opts[imageName] = s.toBuildOptions(project, service, imageName)
 		}
 	}
 
@@ -66,7 +66,7 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 			if localImagePresent && service.PullPolicy != types.PullPolicyBuild {
 				continue
 			}
-			opts[imageName] = s.toBuildOptions(service, project.WorkingDir, imageName)
+			opts[imageName] = s.toBuildOptions(project, service, imageName)
 			continue
 		}
 
@@ -130,7 +130,8 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opts
 	return err
 }
 
-func (s *composeService) toBuildOptions(service types.ServiceConfig, contextPath string, imageTag string) build.Options {
+func (s *composeService) toBuildOptions(project *types.Project, service types.ServiceConfig, imageTag string) build.Options {
+	contextPath := project.WorkingDir
 	var tags []string
 	tags = append(tags, imageTag)
 
@@ -148,6 +149,10 @@ func (s *composeService) toBuildOptions(service types.ServiceConfig, contextPath
 		Tags:      tags,
 		Target:    service.Build.Target,
 		Exports:   []bclient.ExportEntry{{Type: "image", Attrs: map[string]string{}}},
+		Labels: map[string]string{
+			projectLabel: project.Name,
+			serviceLabel: service.Name,
+		},
 	}
 }
 
@@ -0,0 +1,68 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// removeCheckpoints deletes the CRIU checkpoints created for a container, which the daemon keeps once the container is
+// removed. Daemons without checkpoint support have none to delete
+func (s *composeService) removeCheckpoints(ctx context.Context, w progress.Writer, eventName string, container moby.Container) error {
+	checkpoints, err := s.apiClient.CheckpointList(ctx, container.ID, moby.CheckpointListOptions{})
+	if isCheckpointUnsupported(err) {
+		logrus.Warnf("daemon doesn't support checkpoints, none removed for container %s: %v", getCanonicalContainerName(container), err)
+		return nil
+	}
+	if errdefs.IsNotFound(err) {
+		logrus.Debugf("no checkpoints listed for container %s: %v", getCanonicalContainerName(container), err)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to list checkpoints of container %s", getCanonicalContainerName(container))
+	}
+	for _, checkpoint := range checkpoints {
+		w.Event(progress.NewEvent(eventName, progress.Working, fmt.Sprintf("Removing checkpoint %s", checkpoint.Name)))
+		err := s.apiClient.CheckpointDelete(ctx, container.ID, moby.CheckpointDeleteOptions{CheckpointID: checkpoint.Name})
+		if err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to remove checkpoint %s of container %s", checkpoint.Name, getCanonicalContainerName(container))
+		}
+	}
+	return nil
+}
+
+// isCheckpointUnsupported tells whether the daemon rejects checkpoint calls because checkpoints are not supported, as
+// when experimental features are disabled
+func isCheckpointUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errdefs.IsNotImplemented(err) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not supported") || strings.Contains(msg, "not implemented") || strings.Contains(msg, "experimental")
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownRemoveCheckpoints(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.checkpoints["p_web_1"] = []string{"before-upgrade", "nightly"}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: testProject("p", "web", "db"), RemoveCheckpoints: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.checkpoints["p_web_1"]), 0)
+	assert.DeepEqual(t, api.callsTo("CheckpointDelete"), []string{"p_web_1 before-upgrade", "p_web_1 nightly"})
+	assert.Assert(t, api.callIndex("CheckpointList", "p_web_1") < api.callIndex("ContainerRemove", "p_web_1"))
+	assert.Assert(t, api.callIndex("CheckpointDelete", "p_web_1 nightly") < api.callIndex("ContainerRemove", "p_web_1"))
+	assert.Assert(t, contains(w.statusOf("Container p_web_1"), "Removing checkpoint nightly"))
+}
+
+func TestDownRemoveCheckpointsUnsupported(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["CheckpointList p_web_1"] = errdefs.NotImplemented(fmt.Errorf("checkpoint is only supported with experimental daemon"))
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveCheckpoints: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownRemoveCheckpointsNotExperimental(t *testing.T) {
+	for _, msg := range []string{
+		"Error response from daemon: checkpoint is not supported without experimental features",
+		"Error response from daemon: not implemented",
+	} {
+		api := newFakeClient()
+		api.containers = []moby.Container{testContainer("p", "web", 1)}
+		api.errors["CheckpointList p_web_1"] = fmt.Errorf("%s", msg)
+		tested := composeService{apiClient: api}
+
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveCheckpoints: true})
+		assert.NilError(t, err, msg)
+		assert.Equal(t, len(api.containers), 0)
+	}
+}
+
+func TestDownRemoveCheckpointsFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.checkpoints["p_web_1"] = []string{"nightly"}
+	api.errors["CheckpointDelete p_web_1 nightly"] = fmt.Errorf("checkpoint directory is busy")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveCheckpoints: true})
+	assert.Error(t, err, "failed to remove checkpoint nightly of container p_web_1: checkpoint directory is busy")
+	// the container is kept, not to orphan its checkpoints
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestDownKeepsCheckpointsByDefault(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.checkpoints["p_web_1"] = []string{"nightly"}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("CheckpointList")), 0)
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// snapshotTimeFormat is the tag format of images preserving a project state at teardown
+const snapshotTimeFormat = "20060102150405"
+
+// commitReference is the image reference a container is committed to before removal. Containers missing compose
+// labels are committed under their name
+func commitReference(c moby.Container, t time.Time) string {
+	project, service, number := c.Labels[projectLabel], c.Labels[serviceLabel], c.Labels[containerNumberLabel]
+	repository := strings.ToLower(fmt.Sprintf("%s_%s_%s", project, service, number))
+	if project == "" || service == "" || number == "" {
+		repository = c.ID
+		if len(c.Names) > 0 {
+			if name := sanitizeRepository(getCanonicalContainerName(c)); name != "" {
+				repository = name
+			}
+		}
+	}
+	return repository + ":" + t.UTC().Format(snapshotTimeFormat)
+}
+
+// sanitizeRepository turns a name into a valid repository name, lower case alphanumerics separated by underscores
+func sanitizeRepository(name string) string {
+	var b strings.Builder
+	separator := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if separator && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			separator = false
+			b.WriteRune(r)
+			continue
+		}
+		separator = true
+	}
+	return b.String()
+}
+
+// commitContainer preserves the final filesystem state of a container, for later inspection
+func (s *composeService) commitContainer(ctx context.Context, w progress.Writer, container moby.Container, summary *downSummary) error {
+	name := getCanonicalContainerName(container)
+	eventName := "Container " + name
+	reference := commitReference(container, time.Now())
+	w.Event(progress.NewEvent(eventName, progress.Working, "Committing"))
+	_, err := s.apiClient.ContainerCommit(ctx, container.ID, moby.ContainerCommitOptions{
+		Reference: reference,
+		Comment:   fmt.Sprintf("Committed by compose down from container %s", name),
+	})
+	if err != nil {
+		return err
+	}
+	w.Event(progress.NewEvent(eventName, progress.Working, "Committed "+reference))
+	summary.containerCommitted(name, reference)
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestCommitReference(t *testing.T) {
+	c := testContainer("p", "Web", 2)
+	ref := commitReference(c, time.Date(2021, 1, 20, 10, 30, 0, 0, time.UTC))
+	assert.Equal(t, ref, "p_web_2:20210120103000")
+}
+
+func TestCommitReferenceUnlabeled(t *testing.T) {
+	c := moby.Container{ID: "123abc", Names: []string{"/My.Legacy--App_"}}
+	ref := commitReference(c, time.Date(2021, 1, 20, 10, 30, 0, 0, time.UTC))
+	assert.Equal(t, ref, "my_legacy_app:20210120103000")
+
+	c = moby.Container{ID: "123abc", Names: []string{"/--"}}
+	ref = commitReference(c, time.Date(2021, 1, 20, 10, 30, 0, 0, time.UTC))
+	assert.Equal(t, ref, "123abc:20210120103000")
+}
+
+func TestDownCommitBeforeRemove(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:            testProject("p", "web"),
+		CommitBeforeRemove: true,
+		Result:             &result,
+	})
+	assert.NilError(t, err)
+	for _, name := range []string{"p_web_1", "p_web_2"} {
+		stopped := api.callIndex("ContainerStop", name)
+		committed := api.callIndex("ContainerCommit", name)
+		removed := api.callIndex("ContainerRemove", name)
+		assert.Assert(t, stopped < committed && committed < removed, "%s should be committed after stop and before removal", name)
+		assert.Assert(t, strings.HasPrefix(result.CommittedImages[name], name+":"))
+	}
+	assert.Equal(t, len(api.images), 2)
+}
+
+func TestDownCommitFailureKeepsContainer(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerCommit p_web_1"] = fmt.Errorf("no space left on device")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CommitBeforeRemove: true})
+	assert.ErrorContains(t, err, "no space left on device")
+	assert.Equal(t, len(api.containers), 1)
+}
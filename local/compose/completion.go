@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// completionEventID is the ID of the last event Down emits, reporting the outcome of the teardown
+const completionEventID = "Down"
+
+// completionWatcher forwards events while tracking the resources whose last event is an error, so that the completion
+// event tells a partial success from a success
+type completionWatcher struct {
+	progress.Writer
+	mtx sync.Mutex
+	// ids are the IDs of failed events in the order they first failed, failures the error texts of the ones still failed
+	ids      []string
+	failures map[string]string
+}
+
+func newCompletionWatcher(w progress.Writer) *completionWatcher {
+	return &completionWatcher{
+		Writer:   w,
+		failures: map[string]string{},
+	}
+}
+
+func (c *completionWatcher) Event(e progress.Event) {
+	c.mtx.Lock()
+	if e.Status == progress.Error {
+		if !contains(c.ids, e.ID) {
+			c.ids = append(c.ids, e.ID)
+		}
+		c.failures[e.ID] = e.StatusText
+	} else {
+		delete(c.failures, e.ID)
+	}
+	c.mtx.Unlock()
+	c.Writer.Event(e)
+}
+
+// failed returns the resources which failed and were not handled on a later attempt
+func (c *completionWatcher) failed() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	var failed []string
+	for _, id := range c.ids {
+		if text, ok := c.failures[id]; ok {
+			failed = append(failed, fmt.Sprintf("%s: %s", id, text))
+		}
+	}
+	return failed
+}
+
+// complete emits the completion event of the teardown
+func (c *completionWatcher) complete(result compose.DownResult, err error) {
+	c.Writer.Event(progress.CompletionEvent(completionEventID, downCompletion(c.failed(), result, err)))
+}
+
+// downCompletion computes the outcome of a teardown: it's a partial success when resources failed without failing the
+// teardown, or were left behind
+func downCompletion(failed []string, result compose.DownResult, err error) progress.Completion {
+	if err != nil {
+		return progress.Completion{Status: progress.Failed, Errors: append([]string{err.Error()}, failed...)}
+	}
+	for _, volume := range result.PersistingVolumes {
+		failed = append(failed, fmt.Sprintf("anonymous volume %s still exists", volume))
+	}
+	for _, port := range result.HeldPorts {
+		failed = append(failed, fmt.Sprintf("port %s is still bound", port))
+	}
+	for _, rule := range result.StaleFirewallRules {
+		failed = append(failed, fmt.Sprintf("firewall rule %s was left behind", rule))
+	}
+	for _, subnet := range result.StuckSubnets {
+		failed = append(failed, fmt.Sprintf("subnet %s is still allocated", subnet))
+	}
+	if len(failed) > 0 {
+		return progress.Completion{Status: progress.PartialSuccess, Errors: failed}
+	}
+	return progress.Completion{Status: progress.Success}
+}
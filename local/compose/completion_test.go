@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func lastEvent(w *recordingWriter) progress.Event {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.events[len(w.events)-1]
+}
+
+func TestDownCompletionSuccess(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	last := lastEvent(w)
+	assert.Equal(t, last.ID, "Down")
+	assert.Equal(t, last.Status, progress.Done)
+	assert.DeepEqual(t, last.Completion, &progress.Completion{Status: progress.Success})
+}
+
+func TestDownCompletionPartialSuccess(t *testing.T) {
+	api := newFakeClient()
+	leaked, leakedMount := anonymousVolume("e")
+	web := testContainer("p", "web", 1)
+	web.Mounts = []moby.MountPoint{leakedMount}
+	api.containers = []moby.Container{web}
+	api.volumes = []moby.Volume{leaked}
+	api.leaked[leaked.Name] = true
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:              testProject("p", "web"),
+		Volumes:              true,
+		VerifyVolumesRemoved: true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, lastEvent(w).Completion, &progress.Completion{
+		Status: progress.PartialSuccess,
+		Errors: []string{fmt.Sprintf("anonymous volume %s still exists", leaked.Name)},
+	})
+}
+
+func TestDownCompletionFailed(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device busy")
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.ErrorContains(t, err, "device busy")
+	last := lastEvent(w)
+	assert.Equal(t, last.Status, progress.Error)
+	assert.Equal(t, last.Completion.Status, progress.Failed)
+	assert.DeepEqual(t, last.Completion.Errors, []string{
+		err.Error(),
+		"Container p_web_1: Error while Removing",
+		"Service web: 0/1 containers removed",
+	})
+}
+
+func TestCompletionWatcherIgnoresRecoveredFailures(t *testing.T) {
+	w := &recordingWriter{}
+	watcher := newCompletionWatcher(w)
+	watcher.Event(progress.ErrorMessageEvent(`Network "p_default"`, "Error while Removing"))
+	watcher.Event(progress.RemovingEvent(`Network "p_default"`))
+	watcher.Event(progress.RemovedEvent(`Network "p_default"`))
+	watcher.Event(progress.ErrorMessageEvent(`Volume "p_data"`, "Error while Removing"))
+	watcher.Event(progress.ErrorMessageEvent(`Volume "p_data"`, "Error while Removing"))
+
+	assert.Equal(t, len(w.events), 5)
+	watcher.complete(compose.DownResult{}, nil)
+	assert.DeepEqual(t, lastEvent(w).Completion, &progress.Completion{
+		Status: progress.PartialSuccess,
+		Errors: []string{`Volume "p_data": Error while Removing`},
+	})
+}
@@ -33,12 +33,34 @@ import (
 )
 
 // NewComposeService create a local implementation of the compose.Service API
-func NewComposeService(apiClient *client.Client) compose.Service {
-	return &composeService{apiClient: apiClient}
+func NewComposeService(apiClient client.APIClient) compose.Service {
+	return &composeService{
+		apiClient:  apiClient,
+		hostClient: apiClient,
+		firewall:   iptablesInspector{},
+		notifier:   systemNotifier{},
+	}
+}
+
+// NewScopedComposeService create a local implementation of the compose.Service API restricted to resources with the
+// scope labels, for multi-tenant hosts
+func NewScopedComposeService(apiClient client.APIClient, scope map[string]string) compose.Service {
+	return &composeService{
+		apiClient:  scopedClient{APIClient: apiClient, scope: scope},
+		hostClient: apiClient,
+		firewall:   iptablesInspector{},
+		notifier:   systemNotifier{},
+	}
 }
 
 type composeService struct {
-	apiClient *client.Client
+	apiClient client.APIClient
+	// hostClient is never scoped, for the state the daemon shares between all tenants
+	hostClient client.APIClient
+	firewall   firewallInspector
+	notifier   desktopNotifier
+	store      projectStore
+	ipam       ipamInspector
 }
 
 func (s *composeService) Up(ctx context.Context, project *types.Project, options compose.UpOptions) error {
@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// confirmed lets the embedder confirm a destructive action. Actions without resources, or without a Confirm
+// callback, proceed
+func confirmed(options compose.DownOptions, action compose.DestructiveAction) (bool, error) {
+	if options.Confirm == nil || len(action.Resources) == 0 {
+		return true, nil
+	}
+	ok, err := options.Confirm(action)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		logrus.Warnf("removal of %s was not confirmed, skipping", action.Kind)
+	}
+	return ok, nil
+}
+
+// confirmVolumesRemoval asks for the removal of anonymous volumes attached to containers, and of project named volumes
+func (s *composeService) confirmVolumesRemoval(ctx context.Context, projectName string, containers Containers, withNamed bool, options compose.DownOptions) (bool, error) {
+	volumes := containers.anonymousVolumes()
+	if withNamed {
+		list, err := s.apiClient.VolumeList(ctx, filters.NewArgs(projectFilter(projectName)))
+		if err != nil {
+			return false, err
+		}
+		for _, v := range list.Volumes {
+			volumes = append(volumes, v.Name)
+		}
+	}
+	return confirmed(options, compose.DestructiveAction{Kind: compose.VolumesRemoval, Resources: volumes})
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownConfirmVolumesRemoval(t *testing.T) {
+	for _, confirm := range []bool{true, false} {
+		api := newFakeClient()
+		volume, anonymous := anonymousVolume("a")
+		web := testContainer("p", "web", 1)
+		web.Mounts = []moby.MountPoint{anonymous}
+		api.containers = []moby.Container{web}
+		api.volumes = []moby.Volume{volume, testVolume("p", "data", 0)}
+		tested := composeService{apiClient: api}
+
+		var actions []compose.DestructiveAction
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{
+			Project: testProject("p", "web"),
+			Volumes: true,
+			Confirm: func(action compose.DestructiveAction) (bool, error) {
+				actions = append(actions, action)
+				return confirm, nil
+			},
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, actions, []compose.DestructiveAction{
+			{Kind: compose.VolumesRemoval, Resources: []string{volume.Name, "p_data"}},
+		})
+		assert.Equal(t, len(api.containers), 0)
+		if confirm {
+			assert.Equal(t, len(api.volumes), 0)
+		} else {
+			assert.Equal(t, len(api.volumes), 2)
+			assert.Equal(t, len(api.callsTo("VolumeRemove")), 0)
+		}
+	}
+}
+
+func TestDownConfirmImagesRemoval(t *testing.T) {
+	for _, confirm := range []bool{true, false} {
+		api := newFakeClient()
+		api.images = []moby.ImageSummary{
+			{ID: "sha256:dangling", Labels: map[string]string{projectLabel: "p"}},
+			{ID: "sha256:web", RepoTags: []string{"p_web:latest"}, Labels: map[string]string{projectLabel: "p"}},
+		}
+		tested := composeService{apiClient: api}
+
+		var actions []compose.DestructiveAction
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{
+			Project:       testProject("p", "web"),
+			PruneDangling: true,
+			Confirm: func(action compose.DestructiveAction) (bool, error) {
+				actions = append(actions, action)
+				return confirm, nil
+			},
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, actions, []compose.DestructiveAction{
+			{Kind: compose.ImagesRemoval, Resources: []string{"sha256:dangling"}},
+		})
+		if confirm {
+			assert.Equal(t, len(api.images), 1)
+		} else {
+			assert.Equal(t, len(api.images), 2)
+			assert.Equal(t, len(api.callsTo("ImagesPrune")), 0)
+		}
+	}
+}
+
+func TestDownConfirmError(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Volumes: true,
+		Confirm: func(action compose.DestructiveAction) (bool, error) {
+			return false, fmt.Errorf("prompt was interrupted")
+		},
+	})
+	assert.ErrorContains(t, err, "prompt was interrupted")
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestDownNoConfirmWithoutDestructiveAction(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:       testProject("p", "web"),
+		Volumes:       true,
+		PruneDangling: true,
+		Confirm: func(action compose.DestructiveAction) (bool, error) {
+			t.Fatalf("unexpected confirmation of %s removal", action.Kind)
+			return false, nil
+		},
+	})
+	assert.NilError(t, err)
+}
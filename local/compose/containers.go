@@ -16,7 +16,15 @@
 
 package compose
 
-import moby "github.com/docker/docker/api/types"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose-cli/api/compose"
+)
 
 // Containers is a set of moby Container
 type Containers []moby.Container
@@ -31,6 +39,71 @@ func isService(services ...string) containerPredicate {
 	}
 }
 
+// isServiceOrNamed returns a predicate builder matching containers by service label, or by the container naming
+// convention for containers missing this label
+func isServiceOrNamed(projectName string) func(services ...string) containerPredicate {
+	return func(services ...string) containerPredicate {
+		return func(c moby.Container) bool {
+			if service, ok := c.Labels[serviceLabel]; ok {
+				return contains(services, service)
+			}
+			name := getCanonicalContainerName(c)
+			for _, service := range services {
+				prefix := projectName + "_" + service + "_"
+				if !strings.HasPrefix(name, prefix) {
+					continue
+				}
+				if _, err := strconv.Atoi(strings.TrimPrefix(name, prefix)); err == nil {
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+// isResolvedService returns a predicate builder matching containers by the service a resolver maps them to
+func isResolvedService(resolve compose.ServiceResolver) func(services ...string) containerPredicate {
+	return func(services ...string) containerPredicate {
+		return func(c moby.Container) bool {
+			return contains(services, resolve(getCanonicalContainerName(c), c.Labels))
+		}
+	}
+}
+
+// replicas selects containers of services by replica index, from the container number label
+func (containers Containers) replicas(indices map[string][]int, isService func(services ...string) containerPredicate) Containers {
+	return containers.filter(func(c moby.Container) bool {
+		number, err := strconv.Atoi(c.Labels[containerNumberLabel])
+		if err != nil {
+			return false
+		}
+		for service, replicas := range indices {
+			if isService(service)(c) && containsInt(replicas, number) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// isCreatedBefore selects containers created before a point in time
+func isCreatedBefore(t time.Time) containerPredicate {
+	return func(c moby.Container) bool {
+		return time.Unix(c.Created, 0).Before(t)
+	}
+}
+
+// containerStates are the states the engine reports containers in
+var containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// isNotInState selects containers in none of the states
+func isNotInState(states ...string) containerPredicate {
+	return func(c moby.Container) bool {
+		return !contains(states, c.State)
+	}
+}
+
 func isNotService(services ...string) containerPredicate {
 	return func(c moby.Container) bool {
 		service := c.Labels[serviceLabel]
@@ -63,6 +136,18 @@ func (containers Containers) split(predicate containerPredicate) (Containers, Co
 	return right, left
 }
 
+// servicesWithLabel return the services which containers have label set to value
+func (containers Containers) servicesWithLabel(label string, value string) []string {
+	var services []string
+	for _, c := range containers {
+		service := c.Labels[serviceLabel]
+		if c.Labels[label] == value && !contains(services, service) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
 func (containers Containers) names() []string {
 	var names []string
 	for _, c := range containers {
@@ -0,0 +1,49 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestIsServiceOrNamed(t *testing.T) {
+	unlabeled := func(name string) moby.Container {
+		return moby.Container{
+			Names:  []string{"/" + name},
+			Labels: map[string]string{projectLabel: "p"},
+		}
+	}
+	isWeb := isServiceOrNamed("p")("web")
+	assert.Assert(t, isWeb(testContainer("p", "web", 1)))
+	assert.Assert(t, !isWeb(testContainer("p", "db", 1)))
+	assert.Assert(t, isWeb(unlabeled("p_web_2")))
+	assert.Assert(t, !isWeb(unlabeled("p_web_2_backup")))
+	assert.Assert(t, !isWeb(unlabeled("p_webapp_1")))
+	assert.Assert(t, !isWeb(unlabeled("other_web_1")))
+}
+
+func TestIsResolvedService(t *testing.T) {
+	isWeb := isResolvedService(func(container string, labels map[string]string) string {
+		return labels["app"]
+	})("web")
+	assert.Assert(t, isWeb(moby.Container{Names: []string{"/web-1"}, Labels: map[string]string{"app": "web"}}))
+	assert.Assert(t, !isWeb(moby.Container{Names: []string{"/db-1"}, Labels: map[string]string{"app": "db"}}))
+	assert.Assert(t, !isWeb(testContainer("p", "web", 1)))
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/config"
+	apicontext "github.com/docker/compose-cli/api/context"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// contextMetadataFile is the file of the context metadata directory recording where the project model lives
+const contextMetadataFile = "project.json"
+
+// contextMetadataDir is the directory where compose stores metadata of a project scoped to a docker context. Names come
+// from container labels, so the ones which could resolve out of the compose metadata directory are rejected
+func contextMetadataDir(configDir string, contextName string, projectName string) (string, error) {
+	for _, name := range []string{contextName, projectName} {
+		if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+			return "", errors.Errorf("invalid name %q for context metadata", name)
+		}
+	}
+	root := filepath.Join(configDir, "compose")
+	dir := filepath.Join(root, contextName, projectName)
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("context metadata of %s/%s is out of %s", contextName, projectName, root)
+	}
+	return dir, nil
+}
+
+// writeContextMetadata records the model location of a project created under a docker context, for workflows using
+// ephemeral contexts to find the project back. Nothing is recorded without a context nor a config directory
+func (s *composeService) writeContextMetadata(ctx context.Context, project *types.Project) error {
+	configDir := config.Dir(ctx)
+	contextName := apicontext.CurrentContext(ctx)
+	if configDir == "" || contextName == "" {
+		return nil
+	}
+	dir, err := contextMetadataDir(configDir, contextName, project.Name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(projectEntry{WorkingDir: project.WorkingDir, ConfigFiles: project.ComposeFiles}, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal context metadata")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "unable to create context metadata of project %s", project.Name)
+	}
+	return errors.Wrapf(ioutil.WriteFile(filepath.Join(dir, contextMetadataFile), data, 0600), "unable to write context metadata of project %s", project.Name)
+}
+
+func (s *composeService) removeContextMetadata(ctx context.Context, projectName string, containers Containers) error {
+	configDir := config.Dir(ctx)
+	if configDir == "" {
+		return nil
+	}
+	w := progress.ContextWriter(ctx)
+	seen := map[string]bool{}
+	for _, c := range containers {
+		contextName := c.Labels[contextLabel]
+		if contextName == "" || seen[contextName] {
+			continue
+		}
+		seen[contextName] = true
+
+		dir, err := contextMetadataDir(configDir, contextName, projectName)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		eventName := fmt.Sprintf("Context metadata %q", contextName)
+		w.Event(progress.RemovingEvent(eventName))
+		if err := os.RemoveAll(dir); err != nil {
+			w.Event(progress.ErrorEvent(eventName))
+			return err
+		}
+		w.Event(progress.RemovedEvent(eventName))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/config"
+	apicontext "github.com/docker/compose-cli/api/context"
+)
+
+func TestDownRemoveContextMetadata(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	dir, err := contextMetadataDir(configDir.Path(), "remote", "p")
+	assert.NilError(t, err)
+	assert.NilError(t, os.MkdirAll(dir, 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "meta.json"), []byte("{}"), 0644))
+
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.Labels[contextLabel] = "remote"
+	api.containers = []moby.Container{web}
+	tested := composeService{apiClient: api}
+
+	ctx := config.WithDir(context.TODO(), configDir.Path())
+	err = tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), RemoveContextMetadata: true})
+	assert.NilError(t, err)
+	_, err = os.Stat(dir)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestContextMetadataWrittenThenRemovedByDown(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	api := newFakeClient()
+	tested := composeService{apiClient: api}
+	project := testProject("p", "web")
+	project.WorkingDir = "/src/p"
+	project.ComposeFiles = []string{"/src/p/compose.yaml"}
+
+	ctx := apicontext.WithCurrentContext(config.WithDir(context.TODO(), configDir.Path()), "remote")
+	err := tested.writeContextMetadata(ctx, project)
+	assert.NilError(t, err)
+	dir, err := contextMetadataDir(configDir.Path(), "remote", "p")
+	assert.NilError(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(dir, contextMetadataFile))
+	assert.NilError(t, err)
+	var entry projectEntry
+	assert.NilError(t, json.Unmarshal(data, &entry))
+	assert.DeepEqual(t, entry, projectEntry{WorkingDir: "/src/p", ConfigFiles: []string{"/src/p/compose.yaml"}})
+
+	web := testContainer("p", "web", 1)
+	web.Labels[contextLabel] = "remote"
+	api.containers = []moby.Container{web}
+	err = tested.Down(ctx, "p", compose.DownOptions{Project: project, RemoveContextMetadata: true})
+	assert.NilError(t, err)
+	_, err = os.Stat(dir)
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestContextMetadataNotWrittenWithoutContext(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	tested := composeService{apiClient: newFakeClient()}
+
+	err := tested.writeContextMetadata(config.WithDir(context.TODO(), configDir.Path()), testProject("p", "web"))
+	assert.NilError(t, err)
+	_, err = os.Stat(filepath.Join(configDir.Path(), "compose"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestDownRemoveContextMetadataAbsent(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.Labels[contextLabel] = "remote"
+	api.containers = []moby.Container{web, testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+
+	ctx := config.WithDir(context.TODO(), configDir.Path())
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web", "db"), RemoveContextMetadata: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownRemoveContextMetadataEscaping(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	victim := filepath.Join(configDir.Path(), "victim")
+	assert.NilError(t, os.MkdirAll(victim, 0755))
+
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.Labels[contextLabel] = "../.."
+	api.containers = []moby.Container{web}
+	tested := composeService{apiClient: api}
+
+	ctx := config.WithDir(context.TODO(), filepath.Join(configDir.Path(), "victim", "docker"))
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), RemoveContextMetadata: true})
+	assert.ErrorContains(t, err, `invalid name "../.." for context metadata`)
+	_, err = os.Stat(victim)
+	assert.NilError(t, err)
+}
+
+func TestContextMetadataDirRejectsPaths(t *testing.T) {
+	for _, names := range [][2]string{{"..", "p"}, {"a/b", "p"}, {`a\b`, "p"}, {"remote", "../p"}, {"remote", ""}, {"", "p"}} {
+		_, err := contextMetadataDir("/config", names[0], names[1])
+		assert.Assert(t, err != nil, "context %q, project %q", names[0], names[1])
+	}
+	dir, err := contextMetadataDir("/config", "remote", "p")
+	assert.NilError(t, err)
+	assert.Equal(t, dir, filepath.Join("/config", "compose", "remote", "p"))
+}
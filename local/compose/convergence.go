@@ -75,7 +75,8 @@ func (s *composeService) ensureScale(ctx context.Context, actual []moby.Containe
 }
 
 func (s *composeService) ensureService(ctx context.Context, observedState Containers, project *types.Project, service types.ServiceConfig) error {
-	actual := observedState.filter(isService(service.Name))
+	// placeholders left by a previous down carry the service labels, but are not replicas of it
+	actual := observedState.filter(isService(service.Name)).filter(isNotPlaceholder)
 
 	scale, err := getScale(service)
 	if err != nil {
@@ -324,7 +325,7 @@ func (s *composeService) startService(ctx context.Context, project *types.Projec
 		return err
 	}
 	eg, ctx := errgroup.WithContext(ctx)
-	for _, c := range containers {
+	for _, c := range Containers(containers).filter(isNotPlaceholder) {
 		container := c
 		if container.State == status.ContainerRunning {
 			continue
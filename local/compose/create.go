@@ -38,6 +38,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/docker/compose-cli/api/compose"
+	apicontext "github.com/docker/compose-cli/api/context"
 	"github.com/docker/compose-cli/api/progress"
 	convert "github.com/docker/compose-cli/local/moby"
 )
@@ -94,9 +95,21 @@ func (s *composeService) Create(ctx context.Context, project *types.Project, opt
 		}
 	}
 
-	return InDependencyOrder(ctx, project, func(c context.Context, service types.ServiceConfig) error {
+	err = InDependencyOrder(ctx, project, func(c context.Context, service types.ServiceConfig) error {
 		return s.ensureService(c, observedState, project, service)
 	})
+	if err != nil {
+		return err
+	}
+	if opts.ContextMetadata {
+		if err := s.writeContextMetadata(ctx, project); err != nil {
+			return err
+		}
+	}
+	if opts.RecordProject {
+		s.rememberProject(ctx, project)
+	}
+	return nil
 }
 
 func prepareVolumes(p *types.Project) error {
@@ -178,6 +191,7 @@ func (s *composeService) getCreateOptions(ctx context.Context, p *types.Project,
 	labels[projectLabel] = p.Name
 	labels[serviceLabel] = service.Name
 	labels[versionLabel] = ComposeVersion
+	labels[schemaVersionLabel] = strconv.Itoa(schemaVersion)
 	if _, ok := service.Labels[oneoffLabel]; !ok {
 		labels[oneoffLabel] = "False"
 	}
@@ -185,6 +199,9 @@ func (s *composeService) getCreateOptions(ctx context.Context, p *types.Project,
 	labels[workingDirLabel] = p.WorkingDir
 	labels[configFilesLabel] = strings.Join(p.ComposeFiles, ",")
 	labels[containerNumberLabel] = strconv.Itoa(number)
+	if contextName := apicontext.CurrentContext(ctx); contextName != "" {
+		labels[contextLabel] = contextName
+	}
 
 	var (
 		runCmd     strslice.StrSlice
@@ -620,17 +637,30 @@ func (s *composeService) ensureNetwork(ctx context.Context, n types.NetworkConfi
 	return nil
 }
 
-func (s *composeService) ensureNetworkDown(ctx context.Context, networkID string, networkName string) error {
+func (s *composeService) ensureNetworkDown(ctx context.Context, projectName string, networkID string, networkName string, summary *downSummary) error {
 	w := progress.ContextWriter(ctx)
 	eventName := fmt.Sprintf("Network %q", networkName)
 	w.Event(progress.RemovingEvent(eventName))
 
-	if err := s.apiClient.NetworkRemove(ctx, networkID); err != nil {
+	err := s.apiClient.NetworkRemove(ctx, networkID)
+	if isActiveEndpointsError(err) {
+		if services, inspectErr := s.swarmServices(ctx, networkID); inspectErr == nil && len(services) > 0 {
+			logrus.Warnf("network %s is in use by swarm services %s, it will not be removed", networkName, strings.Join(services, ", "))
+			w.Event(progress.NewEvent(eventName, progress.Done, "Kept, in use by swarm services"))
+			return nil
+		}
+		err = s.disconnectLeftoverEndpoints(ctx, projectName, networkID, eventName)
+		if err == nil {
+			err = s.apiClient.NetworkRemove(ctx, networkID)
+		}
+	}
+	if err != nil {
 		w.Event(progress.ErrorEvent(eventName))
 		return errors.Wrapf(err, fmt.Sprintf("failed to create network %s", networkID))
 	}
 
 	w.Event(progress.RemovedEvent(eventName))
+	summary.networkRemoved()
 	return nil
 }
 
@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// deadlineWarningRatio is the share of the time left until the context deadline it is considered close
+const deadlineWarningRatio = 0.2
+
+// warnBeforeDeadline warns when the teardown is still running close to the context deadline, so users know why it
+// may get cut off. The returned func must be called once the teardown is done
+func warnBeforeDeadline(ctx context.Context) func() {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return func() {}
+	}
+	left := time.Until(deadline)
+	margin := time.Duration(float64(left) * deadlineWarningRatio)
+	timer := time.AfterFunc(left-margin, func() {
+		w := progress.ContextWriter(ctx)
+		msg := fmt.Sprintf("teardown deadline in %s, remaining resources may not be removed", time.Until(deadline).Round(time.Millisecond))
+		logrus.Warn(msg)
+		w.Event(progress.NewEvent("Deadline", progress.Working, msg))
+	})
+	return func() {
+		timer.Stop()
+	}
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownDeadlineWarning(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx, cancel := context.WithTimeout(progress.WithContextWriter(context.TODO(), w), 200*time.Millisecond)
+	defer cancel()
+
+	// drain grace period outlasts the deadline
+	err := tested.Down(ctx, "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Signal: "SIGUSR1", GracePeriod: time.Second},
+	})
+	assert.ErrorContains(t, err, "context deadline exceeded")
+	warnings := w.statusOf("Deadline")
+	assert.Equal(t, len(warnings), 1)
+	assert.Assert(t, strings.HasPrefix(warnings[0], "teardown deadline in "))
+}
+
+func TestDownNoDeadlineWarning(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx, cancel := context.WithTimeout(progress.WithContextWriter(context.TODO(), w), time.Second)
+	defer cancel()
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(w.statusOf("Deadline")), 0)
+}
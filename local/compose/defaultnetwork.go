@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// defaultNetworkName is the name of the network compose creates for services which don't declare networks, unless
+// the project overrides it with `networks.default.name`
+func defaultNetworkName(project *types.Project, projectName string) string {
+	if project != nil {
+		if n, ok := project.Networks["default"]; ok && n.Name != "" {
+			return n.Name
+		}
+	}
+	return fmt.Sprintf("%s_default", projectName)
+}
+
+// reportUnlabeledDefaultNetwork reports the project default network as kept, when the project renames it and the
+// network doesn't carry the project label so that label discovery misses it. Such a network may pre-exist the project
+// and only be reused by compose, it is never removed
+func (s *composeService) reportUnlabeledDefaultNetwork(ctx context.Context, project *types.Project, projectName string, networks []moby.NetworkResource) {
+	if project == nil {
+		return
+	}
+	config, ok := project.Networks["default"]
+	if !ok || config.Name == "" || config.External.External {
+		return
+	}
+	for _, n := range networks {
+		if n.Name == config.Name {
+			return
+		}
+	}
+	n, err := s.apiClient.NetworkInspect(ctx, config.Name, moby.NetworkInspectOptions{})
+	// a network out of the client scope belongs to someone else
+	if errdefs.IsNotFound(err) || errdefs.IsForbidden(err) {
+		return
+	}
+	if err != nil {
+		logrus.Warnf("unable to inspect default network %s: %v", config.Name, err)
+		return
+	}
+	if owner, ok := n.Labels[projectLabel]; ok && owner != projectName {
+		return
+	}
+	logrus.Warnf("default network %s is not labeled for project %s, it will not be removed", config.Name, projectName)
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(fmt.Sprintf("Network %q", config.Name), progress.Done, "Kept, not created by compose"))
+}
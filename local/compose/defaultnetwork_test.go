@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func renamedDefaultProject(name string) *types.Project {
+	project := testProject("p", "web")
+	project.Networks = types.Networks{"default": types.NetworkConfig{Name: name}}
+	return project
+}
+
+func TestDefaultNetworkName(t *testing.T) {
+	assert.Equal(t, defaultNetworkName(nil, "p"), "p_default")
+	assert.Equal(t, defaultNetworkName(testProject("p", "web"), "p"), "p_default")
+	assert.Equal(t, defaultNetworkName(renamedDefaultProject("backbone"), "p"), "backbone")
+}
+
+func TestDownKeepsUnlabeledRenamedDefaultNetwork(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{{ID: "f00d", Name: "backbone", Labels: map[string]string{}}}
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: renamedDefaultProject("backbone"), Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.callsTo("NetworkRemove")), 0)
+	assert.Equal(t, result.Networks, 0)
+	assert.DeepEqual(t, w.statusOf(`Network "backbone"`), []string{"Kept, not created by compose"})
+}
+
+func TestDownRenamedDefaultNetworkDiscoveredByLabels(t *testing.T) {
+	api := newFakeClient()
+	network := testNetwork("p", "default")
+	network.Name = "backbone"
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: renamedDefaultProject("backbone")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default"})
+	assert.Equal(t, len(api.callsTo("NetworkInspect")), 0)
+}
+
+func TestDownKeepsRenamedDefaultNetworkOfAnotherProject(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{{ID: "f00d", Name: "backbone", Labels: map[string]string{projectLabel: "other"}}}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: renamedDefaultProject("backbone")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.callsTo("NetworkRemove")), 0)
+}
+
+func TestDownMissingRenamedDefaultNetwork(t *testing.T) {
+	api := newFakeClient()
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: renamedDefaultProject("backbone")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("NetworkInspect"), []string{"backbone"})
+}
@@ -167,11 +167,26 @@ func NewGraph(services types.Services, initialStatus ServiceStatus) *Graph {
 		for _, name := range s.GetDependencies() {
 			_ = graph.AddEdge(s.Name, name)
 		}
+		for _, name := range linkedServices(s) {
+			_ = graph.AddEdge(s.Name, name)
+		}
 	}
 
 	return graph
 }
 
+// linkedServices returns the services a service declares legacy `links` to, which imply a dependency
+func linkedServices(s types.ServiceConfig) []string {
+	var services []string
+	for _, link := range s.Links {
+		name := strings.SplitN(link, ":", 2)[0]
+		if !contains(services, name) {
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
 // NewVertex is the constructor function for the Vertex
 func NewVertex(key string, service types.ServiceConfig, initialStatus ServiceStatus) *Vertex {
 	return &Vertex{
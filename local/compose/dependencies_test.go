@@ -67,3 +67,59 @@ func TestInDependencyReverseDownCommandOrder(t *testing.T) {
 	assert.Equal(t, <-order, "test2")
 	assert.Equal(t, <-order, "test3")
 }
+
+func TestInDependencyReverseDownCommandOrderHealthCondition(t *testing.T) {
+	project := types.Project{
+		Services: []types.ServiceConfig{
+			{
+				Name: "web",
+				DependsOn: map[string]types.ServiceDependency{
+					"db": {Condition: "service_healthy"},
+				},
+			},
+			{
+				Name: "db",
+			},
+		},
+	}
+	order := make(chan string)
+	//nolint:errcheck, unparam
+	go InReverseDependencyOrder(context.TODO(), &project, func(ctx context.Context, config types.ServiceConfig) error {
+		order <- config.Name
+		return nil
+	})
+	assert.Equal(t, <-order, "web")
+	assert.Equal(t, <-order, "db")
+}
+
+func TestInDependencyReverseDownCommandOrderLinks(t *testing.T) {
+	project := types.Project{
+		Services: []types.ServiceConfig{
+			{
+				Name:  "web",
+				Links: []string{"api:backend"},
+			},
+			{
+				Name:  "api",
+				Links: []string{"db"},
+			},
+			{
+				Name: "db",
+			},
+		},
+	}
+	order := make(chan string)
+	//nolint:errcheck, unparam
+	go InReverseDependencyOrder(context.TODO(), &project, func(ctx context.Context, config types.ServiceConfig) error {
+		order <- config.Name
+		return nil
+	})
+	assert.Equal(t, <-order, "web")
+	assert.Equal(t, <-order, "api")
+	assert.Equal(t, <-order, "db")
+}
+
+func TestLinkedServices(t *testing.T) {
+	service := types.ServiceConfig{Name: "web", Links: []string{"db", "db:database", "cache:redis"}}
+	assert.DeepEqual(t, linkedServices(service), []string{"db", "cache"})
+}
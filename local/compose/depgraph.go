@@ -0,0 +1,115 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// runtimeDependency is a container another one relies on at runtime
+type runtimeDependency struct {
+	container string
+	kind      string
+}
+
+// warnDependencyGraphDrift warns about dependencies between running containers the model doesn't declare. The teardown
+// order is computed from the model, so such a dependency could be removed first
+func warnDependencyGraphDrift(ctx context.Context, project *types.Project, containers Containers, inspector *inspectCache) {
+	drift, err := dependencyGraphDrift(ctx, project, containers, inspector)
+	if err != nil {
+		logrus.Warnf("failed to verify the dependency graph of project %s: %v", project.Name, err)
+		return
+	}
+	for _, issue := range drift {
+		logrus.Warn(issue)
+	}
+}
+
+func dependencyGraphDrift(ctx context.Context, project *types.Project, containers Containers, inspector *inspectCache) ([]string, error) {
+	services := map[string]string{}
+	for _, c := range containers {
+		services[c.ID] = c.Labels[serviceLabel]
+		services[getCanonicalContainerName(c)] = c.Labels[serviceLabel]
+	}
+	var drift []string
+	reported := map[string]bool{}
+	for _, c := range containers {
+		service := c.Labels[serviceLabel]
+		config, err := project.GetService(service)
+		if err != nil {
+			// orphan containers declare no dependencies
+			continue
+		}
+		declared := declaredDependencies(config)
+		inspected, err := inspector.inspect(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependency := range runtimeDependencies(inspected) {
+			target, ok := services[dependency.container]
+			if !ok || target == service || contains(declared, target) || reported[service+" "+target] {
+				continue
+			}
+			reported[service+" "+target] = true
+			drift = append(drift, fmt.Sprintf("service %s depends on service %s through the %s of container %s, which the model doesn't declare: %s could be removed first",
+				service, target, dependency.kind, getCanonicalContainerName(c), target))
+		}
+	}
+	return drift, nil
+}
+
+// declaredDependencies returns the services a service depends on, links to, shares the network stack of, or mounts the
+// volumes of
+func declaredDependencies(service types.ServiceConfig) []string {
+	dependencies := append(service.GetDependencies(), linkedServices(service)...)
+	if strings.HasPrefix(service.NetworkMode, "service:") {
+		dependencies = append(dependencies, strings.TrimPrefix(service.NetworkMode, "service:"))
+	}
+	for _, from := range service.VolumesFrom {
+		if !strings.HasPrefix(from, "container:") {
+			dependencies = append(dependencies, strings.SplitN(from, ":", 2)[0])
+		}
+	}
+	return dependencies
+}
+
+// runtimeDependencies returns the containers a container is linked to, shares the network stack of, or mounts the volumes of
+func runtimeDependencies(container moby.ContainerJSON) []runtimeDependency {
+	if container.ContainerJSONBase == nil || container.HostConfig == nil {
+		return nil
+	}
+	var dependencies []runtimeDependency
+	for _, link := range container.HostConfig.Links {
+		// links are formatted as /<target>:/<container>/<alias>
+		name := strings.SplitN(link, ":", 2)[0]
+		dependencies = append(dependencies, runtimeDependency{container: strings.TrimPrefix(name, "/"), kind: "link"})
+	}
+	if mode := container.HostConfig.NetworkMode; mode.IsContainer() {
+		dependencies = append(dependencies, runtimeDependency{container: mode.ConnectedContainer(), kind: "network mode"})
+	}
+	for _, from := range container.HostConfig.VolumesFrom {
+		name := strings.SplitN(from, ":", 2)[0]
+		dependencies = append(dependencies, runtimeDependency{container: name, kind: "volumes_from"})
+	}
+	return dependencies
+}
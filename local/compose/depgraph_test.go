@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDependencyGraphDrift(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1),
+		testContainer("p", "sidecar", 1),
+		testContainer("p", "db", 1),
+		testContainer("p", "backup", 1),
+		testContainer("p", "orphan", 1),
+	}
+	// web declares db, but is also linked to the cache it relies on
+	api.hostConfigs["p_web_1"] = container.HostConfig{Links: []string{"/p_db_1:/p_web_1/db", "/p_backup_1:/p_web_1/backup"}}
+	api.hostConfigs["p_sidecar_1"] = container.HostConfig{NetworkMode: "container:p_web_1"}
+	api.hostConfigs["p_backup_1"] = container.HostConfig{VolumesFrom: []string{"p_db_1:ro"}}
+	api.hostConfigs["p_orphan_1"] = container.HostConfig{Links: []string{"/p_db_1:/p_orphan_1/db"}}
+	project := &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "web", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		{Name: "sidecar"},
+		{Name: "db"},
+		{Name: "backup", VolumesFrom: []string{"db:ro"}},
+	}}
+
+	drift, err := dependencyGraphDrift(context.TODO(), project, api.containers, newInspectCache(api))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, drift, []string{
+		"service web depends on service backup through the link of container p_web_1, which the model doesn't declare: backup could be removed first",
+		"service sidecar depends on service web through the network mode of container p_sidecar_1, which the model doesn't declare: web could be removed first",
+	})
+}
+
+func TestDependencyGraphDriftReportedOncePerServices(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "db", 1)}
+	api.hostConfigs["p_web_1"] = container.HostConfig{Links: []string{"/p_db_1:/p_web_1/db"}}
+	api.hostConfigs["p_web_2"] = container.HostConfig{Links: []string{"/p_db_1:/p_web_2/db"}}
+
+	drift, err := dependencyGraphDrift(context.TODO(), testProject("p", "web", "db"), api.containers, newInspectCache(api))
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift), 1)
+}
+
+func TestDownVerifyDependencyGraph(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.hostConfigs["p_web_1"] = container.HostConfig{NetworkMode: "container:p_db_1"}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), VerifyDependencyGraph: true})
+	assert.NilError(t, err)
+	// drift is only reported
+	assert.Equal(t, len(api.containers), 0)
+	assert.Assert(t, api.callIndex("ContainerInspect", "p_web_1") < api.callIndex("ContainerStop", "p_web_1"))
+}
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"io/ioutil"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/pkg/errors"
+	"github.com/sanathkr/go-yaml"
+)
+
+// desiredState describes the services which should not exist in a project, as maintained by a GitOps repository
+type desiredState struct {
+	Project string   `yaml:"project"`
+	Absent  []string `yaml:"absent"`
+}
+
+// absentServices loads a desired state descriptor, and returns the services of the project it requires to be absent
+func absentServices(file string, project *types.Project) ([]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid desired state file %s", file)
+	}
+	var state desiredState
+	if err := yaml.Unmarshal(content, &state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse desired state file %s", file)
+	}
+	if err := state.validate(project); err != nil {
+		return nil, errors.Wrapf(err, "desired state file %s does not match project %s", file, project.Name)
+	}
+	return state.Absent, nil
+}
+
+func (state desiredState) validate(project *types.Project) error {
+	if state.Project != "" && state.Project != project.Name {
+		return errors.Errorf("desired state is for project %s", state.Project)
+	}
+	seen := map[string]bool{}
+	for _, service := range state.Absent {
+		if seen[service] {
+			return errors.Errorf("service %s is listed twice", service)
+		}
+		seen[service] = true
+		if !contains(project.ServiceNames(), service) {
+			return errors.Errorf("no such service: %s", service)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownDesiredState(t *testing.T) {
+	dir := fs.NewDir(t, "desired", fs.WithFile("state.yaml", "project: p\nabsent:\n  - worker\n  - cache\n"))
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1),
+		testContainer("p", "worker", 1),
+		testContainer("p", "worker", 2),
+		testContainer("p", "db", 1),
+	}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:          testProject("p", "web", "worker", "db", "cache"),
+		DesiredStateFile: dir.Join("state.yaml"),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 2)
+	assert.DeepEqual(t, Containers(api.containers).names(), []string{"p_web_1", "p_db_1"})
+	// services still desired rely on the project network
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownDesiredStateJSON(t *testing.T) {
+	dir := fs.NewDir(t, "desired", fs.WithFile("state.json", `{"absent": ["db"]}`))
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:          testProject("p", "web", "db"),
+		DesiredStateFile: dir.Join("state.json"),
+		RemoveOrphans:    true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_db_1"})
+}
+
+func TestDownDesiredStateValidation(t *testing.T) {
+	dir := fs.NewDir(t, "desired",
+		fs.WithFile("unknown.yaml", "absent: [web, queue]\n"),
+		fs.WithFile("other.yaml", "project: q\nabsent: [web]\n"),
+		fs.WithFile("twice.yaml", "absent: [web, web]\n"),
+		fs.WithFile("invalid.yaml", "absent: web: db\n"),
+	)
+	defer dir.Remove()
+
+	for file, expected := range map[string]string{
+		"unknown.yaml": "does not match project p: no such service: queue",
+		"other.yaml":   "does not match project p: desired state is for project q",
+		"twice.yaml":   "does not match project p: service web is listed twice",
+		"invalid.yaml": "failed to parse desired state file",
+		"missing.yaml": "invalid desired state file",
+	} {
+		api := newFakeClient()
+		api.containers = []moby.Container{testContainer("p", "web", 1)}
+		tested := composeService{apiClient: api}
+
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{
+			Project:          testProject("p", "web"),
+			DesiredStateFile: dir.Join(file),
+		})
+		assert.ErrorContains(t, err, expected, file)
+		assert.Equal(t, len(api.containers), 1, file)
+	}
+}
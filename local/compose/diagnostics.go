@@ -0,0 +1,159 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+const diagnosticsLogTail = "100"
+
+// collectDiagnostics writes a bundle with the project model, containers inspect and last logs and networks inspect.
+// Diagnostics are best effort and must not prevent teardown, so failures are only reported as warnings
+func (s *composeService) collectDiagnostics(ctx context.Context, path string, projectName string, project *types.Project, containers Containers, inspector *inspectCache) {
+	w := progress.ContextWriter(ctx)
+	eventName := "Diagnostics " + path
+	w.Event(progress.NewEvent(eventName, progress.Working, "Collecting"))
+	if err := s.writeDiagnostics(ctx, path, projectName, project, containers, inspector); err != nil {
+		logrus.Warnf("failed to collect diagnostics: %v", err)
+		w.Event(progress.ErrorEvent(eventName))
+		return
+	}
+	w.Event(progress.NewEvent(eventName, progress.Done, "Collected"))
+}
+
+func (s *composeService) writeDiagnostics(ctx context.Context, path string, projectName string, project *types.Project, containers Containers, inspector *inspectCache) error {
+	// the bundle holds container environments and logs, it is only readable by its owner
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint:errcheck
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	model, err := s.Convert(ctx, project, compose.ConvertOptions{Format: "yaml"})
+	if err != nil {
+		logrus.Warnf("diagnostics: failed to convert compose model: %v", err)
+	} else if err := addToBundle(tw, "compose.yaml", model); err != nil {
+		return err
+	}
+
+	for _, c := range containers {
+		name := getCanonicalContainerName(c)
+		inspect, err := inspector.inspect(ctx, c.ID)
+		if err != nil {
+			logrus.Warnf("diagnostics: failed to inspect container %s: %v", name, err)
+			continue
+		}
+		if err := addJSONToBundle(tw, "containers/"+name+".json", inspect); err != nil {
+			return err
+		}
+		logs, err := s.containerLastLogs(ctx, inspect)
+		if err != nil {
+			logrus.Warnf("diagnostics: failed to collect logs for container %s: %v", name, err)
+			continue
+		}
+		if err := addToBundle(tw, "containers/"+name+".log", logs); err != nil {
+			return err
+		}
+	}
+
+	networks, err := s.apiClient.NetworkList(ctx, moby.NetworkListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+	})
+	if err != nil {
+		logrus.Warnf("diagnostics: failed to list networks: %v", err)
+	}
+	for _, n := range networks {
+		inspect, err := s.apiClient.NetworkInspect(ctx, n.ID, moby.NetworkInspectOptions{})
+		if err != nil {
+			logrus.Warnf("diagnostics: failed to inspect network %s: %v", n.Name, err)
+			continue
+		}
+		if err := addJSONToBundle(tw, "networks/"+n.Name+".json", inspect); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (s *composeService) containerLastLogs(ctx context.Context, container moby.ContainerJSON) ([]byte, error) {
+	r, err := s.apiClient.ContainerLogs(ctx, container.ID, moby.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       diagnosticsLogTail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() // nolint:errcheck
+
+	var buf bytes.Buffer
+	if container.Config != nil && container.Config.Tty {
+		_, err = io.Copy(&buf, r)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, r)
+	}
+	return buf.Bytes(), err
+}
+
+func addJSONToBundle(tw *tar.Writer, name string, o interface{}) error {
+	content, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	return addToBundle(tw, name, content)
+}
+
+func addToBundle(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(content)
+	return err
+}
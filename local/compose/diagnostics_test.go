@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownDiagnostics(t *testing.T) {
+	dir := fs.NewDir(t, "diagnostics")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.logs["p_web_1"] = "web is failing\n"
+	tested := composeService{apiClient: api}
+
+	bundle := dir.Join("diagnostics.tar.gz")
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), DiagnosticsTo: bundle})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+
+	content := readBundle(t, bundle)
+	assert.Equal(t, len(content), 4)
+	assert.Assert(t, content["compose.yaml"] != "")
+	assert.Assert(t, content["containers/p_web_1.json"] != "")
+	assert.Equal(t, content["containers/p_web_1.log"], "web is failing\n")
+	assert.Assert(t, content["networks/p_default.json"] != "")
+	assert.Assert(t, api.callIndex("ContainerLogs", "p_web_1") < api.callIndex("ContainerStop", "p_web_1"))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(bundle)
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestDownDiagnosticsFailureIsNotFatal(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), DiagnosticsTo: "/does/not/exist/diagnostics.tar.gz"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func readBundle(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close() // nolint:errcheck
+	gz, err := gzip.NewReader(f)
+	assert.NilError(t, err)
+	tr := tar.NewReader(gz)
+	content := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return content
+		}
+		assert.NilError(t, err)
+		b, err := ioutil.ReadAll(tr)
+		assert.NilError(t, err)
+		content[header.Name] = string(b)
+	}
+}
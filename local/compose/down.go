@@ -18,8 +18,13 @@ package compose
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/docker/compose-cli/api/compose"
 
@@ -29,76 +34,558 @@ import (
 	"github.com/compose-spec/compose-go/types"
 	moby "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
 func (s *composeService) Down(ctx context.Context, projectName string, options compose.DownOptions) error {
-	eg, _ := errgroup.WithContext(ctx)
+	if options.RateLimit > 0 {
+		limited := *s
+		limiter := newCallLimiter(options.RateLimit)
+		limited.apiClient = rateLimitedClient{APIClient: s.apiClient, limiter: limiter}
+		if s.hostClient != nil {
+			limited.hostClient = rateLimitedClient{APIClient: s.hostClient, limiter: limiter}
+		}
+		options.RateLimit = 0
+		return limited.Down(ctx, projectName, options)
+	}
+	if options.AuditSink != nil {
+		sink := options.AuditSink
+		log := &auditLog{project: projectName, actor: currentUser()}
+		audited := *s
+		audited.apiClient = auditedClient{APIClient: s.apiClient, log: log}
+		options.AuditSink = nil
+		err := audited.Down(ctx, projectName, options)
+		if flushErr := log.flush(sink); err == nil {
+			err = flushErr
+		}
+		return err
+	}
+	if options.RecordEventsTo != "" {
+		return s.downRecordingEvents(ctx, projectName, options)
+	}
+	if options.MaskEvents {
+		ctx = progress.WithContextWriter(ctx, progress.NewMaskingWriter(progress.ContextWriter(ctx)))
+	}
+	completion := newCompletionWatcher(progress.ContextWriter(ctx))
+	ctx = progress.WithContextWriter(ctx, completion)
+	defer warnBeforeDeadline(ctx)()
+	if options.Result == nil {
+		options.Result = &compose.DownResult{}
+	}
+	var metrics *downMetrics
+	if options.MetricsTo != "" {
+		metrics = &downMetrics{}
+		ctx = withDownMetrics(ctx, metrics)
+	}
+	done := metrics.phase(phaseTotal)
+	err := s.downWithRetries(ctx, projectName, options)
+	done()
+	if err == nil && len(options.PostDownCommand) > 0 {
+		err = runPostDownCommand(ctx, options.PostDownCommand, options.PostDownCommandFatal, projectName, *options.Result)
+	}
+	if options.HistoryFile != "" {
+		recordHistory(options.HistoryFile, projectName, *options.Result, err)
+	}
+	if metrics != nil {
+		exportMetrics(ctx, options.MetricsTo, projectName, metrics, *options.Result, err)
+	}
+	if options.Notify {
+		s.notifyDown(ctx, projectName, *options.Result, err)
+	}
+	completion.complete(*options.Result, err)
+	return err
+}
+
+func (s *composeService) down(ctx context.Context, projectName string, options compose.DownOptions) error {
 	w := progress.ContextWriter(ctx)
 
-	if options.Project == nil {
-		project, err := s.projectFromContainerLabels(ctx, projectName)
-		if err != nil {
-			return err
-		}
-		options.Project = project
+	if err := validateDownOptions(options); err != nil {
+		return err
+	}
+
+	if options.CleanupResiduals {
+		return s.cleanupResiduals(ctx, projectName, options)
+	}
+
+	project, err := s.downProject(ctx, projectName, options)
+	if err != nil {
+		return err
+	}
+	options.Project = project
+	if options.DiffHistory {
+		reportHistoryDiff(ctx, options.HistoryFile, project)
 	}
 
 	var containers Containers
-	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+	containers, err = s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
 		Filters: filters.NewArgs(projectFilter(options.Project.Name)),
 		All:     true,
 	})
 	if err != nil {
 		return err
 	}
+	projectContainers := containers
+	if options.ValidateLabels {
+		warnLabelsIntegrity(projectName, projectContainers)
+	}
+
+	isDownService := downServiceMatcher(options)
+
+	// when only some services are removed, resources shared by the project are kept
+	containers, partial, err := restrictDownContainers(&options, containers, isDownService)
+	if err != nil {
+		return err
+	}
+
+	inspector := newInspectCache(s.apiClient)
+	defer func() {
+		calls, requests := inspector.stats()
+		logrus.Debugf("inspected %d containers for %d inspect requests", calls, requests)
+	}()
+
+	if err := s.checkBeforeDown(ctx, projectName, containers, isDownService, partial, options, inspector); err != nil {
+		return err
+	}
+
+	if options.RemovalStrategy == nil {
+		options.RemovalStrategy = parallelRemovalStrategy{}
+	}
+	summary := &downSummary{}
+	summary.projectSnapshot(project)
+	if options.Result != nil {
+		defer func() {
+			*options.Result = summary.result()
+		}()
+	}
+
+	images, anonymousVolumes, err := s.prepareDown(ctx, projectName, containers, isDownService, partial, &options, inspector)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := withTeardownGrace(ctx, options.TeardownGracePeriod)
+	defer stop()
+
+	// subscribe before anything is removed, not to miss destroy events
+	removals := s.watchContainerRemovals(ctx, projectName, options)
+	defer removals.stop()
+
+	done := downMetricsFromContext(ctx).phase(phaseContainers)
+	err = s.removeProjectContainers(ctx, w, containers, isDownService, options, summary, inspector)
+	done()
+	if err == nil {
+		err = s.verifyRemovals(ctx, removals, anonymousVolumes, summary)
+	}
+	// services kept by the ServiceTeardown callback still rely on resources shared by the project
+	if err != nil || partial || summary.servicesKept() {
+		return err
+	}
+	return s.cleanupProject(ctx, projectName, projectContainers, images, options, summary)
+}
+
+// checkBeforeDown checks the daemon is writable, verifies the dependency graph, collects diagnostics, checks dry-run
+// preconditions and writes the undo script before anything is removed
+func (s *composeService) checkBeforeDown(ctx context.Context, projectName string, containers Containers, isDownService func(services ...string) containerPredicate,
+	partial bool, options compose.DownOptions, inspector *inspectCache) error {
+	if options.CheckWritable {
+		if err := s.checkDaemonWritable(ctx, projectName); err != nil {
+			return err
+		}
+	}
+
+	if options.VerifyDependencyGraph {
+		warnDependencyGraphDrift(ctx, options.Project, containers, inspector)
+	}
+
+	if options.DiagnosticsTo != "" {
+		s.collectDiagnostics(ctx, options.DiagnosticsTo, projectName, options.Project, containers, inspector)
+	}
+
+	if options.DryRunThenExecute {
+		toRemove := containers
+		if !options.RemoveOrphans {
+			toRemove = containers.filter(isDownService(options.Project.ServiceNames()...))
+		}
+		if err := s.checkDownPreconditions(ctx, projectName, toRemove, !partial, inspector); err != nil {
+			return err
+		}
+	}
+
+	if options.UndoScript != "" {
+		return writeUndoScript(options.UndoScript, options.Project)
+	}
+	return nil
+}
+
+// prepareDown confirms volumes removal and resolves the images and anonymous volumes to clean up once containers are removed
+func (s *composeService) prepareDown(ctx context.Context, projectName string, containers Containers, isDownService func(services ...string) containerPredicate,
+	partial bool, options *compose.DownOptions, inspector *inspectCache) ([]string, []string, error) {
+	var err error
+	if options.Volumes && options.Confirm != nil {
+		options.Volumes, err = s.confirmVolumesRemoval(ctx, projectName, containers, !partial, *options)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var images []string
+	if options.RemoveImages != "" && !partial {
+		images, err = s.resolveServiceImages(ctx, options.Project, containers, options.RemoveImages, isDownService, inspector)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var anonymousVolumes []string
+	if options.Volumes && options.VerifyVolumesRemoved {
+		anonymousVolumes = containers.anonymousVolumes()
+	}
+	return images, anonymousVolumes, nil
+}
+
+func validateDownOptions(options compose.DownOptions) error {
+	if options.Drain != nil && len(options.Drain.Command) > 0 && options.Drain.Signal != "" {
+		return errors.New("drain command and signal are mutually exclusive")
+	}
+	if options.RemoveImages != "" && options.RemoveImages != compose.RemoveImagesAll && options.RemoveImages != compose.RemoveImagesLocal {
+		return errors.Errorf("invalid images removal %q, expected %q or %q", options.RemoveImages, compose.RemoveImagesAll, compose.RemoveImagesLocal)
+	}
+	if options.DiffHistory && options.HistoryFile == "" {
+		return errors.New("history diff requires a history file")
+	}
+	if options.MemoryOrder && options.RemovalStrategy != nil {
+		return errors.New("memory order can't be combined with a removal strategy")
+	}
+	if options.ServiceGroupLabel != "" && options.ServiceGroup == "" {
+		return errors.New("service group label requires a service group")
+	}
+	if options.StopCheck != nil && len(options.StopCheck.Command) == 0 {
+		return errors.New("stop check command is required")
+	}
+	return validateKeepStates(options.KeepStates)
+}
+
+func validateKeepStates(states []string) error {
+	for _, state := range states {
+		if !contains(containerStates, state) {
+			return errors.Errorf("invalid container state %q, expected one of %s", state, strings.Join(containerStates, ", "))
+		}
+	}
+	return nil
+}
+
+// downServiceMatcher selects how containers are attributed to the services being removed
+func downServiceMatcher(options compose.DownOptions) func(services ...string) containerPredicate {
+	if options.ServiceResolver != nil {
+		return isResolvedService(options.ServiceResolver)
+	}
+	if options.MatchServiceByName {
+		return isServiceOrNamed(options.Project.Name)
+	}
+	return isService
+}
+
+// restrictDownContainers narrows the project and containers to the requested service group, replicas, desired state, age
+// or states, reporting whether only part of the project is being removed
+func restrictDownContainers(options *compose.DownOptions, containers Containers, isDownService func(services ...string) containerPredicate) (Containers, bool, error) {
+	partial := false
+	if options.Placeholders {
+		containers = containers.filter(isNotPlaceholder)
+	}
 
-	err = InReverseDependencyOrder(ctx, options.Project, func(c context.Context, service types.ServiceConfig) error {
-		serviceContainers, others := containers.split(isService(service.Name))
-		err := s.removeContainers(ctx, w, eg, serviceContainers)
+	if options.ServiceGroupLabel != "" {
+		options.Project = restrictServices(options.Project, containers.servicesWithLabel(options.ServiceGroupLabel, options.ServiceGroup))
+		containers = containers.filter(isDownService(options.Project.ServiceNames()...))
+		partial = true
+	}
+
+	if len(options.ServiceReplicas) > 0 {
+		var services []string
+		for service := range options.ServiceReplicas {
+			if !contains(options.Project.ServiceNames(), service) {
+				return nil, false, errors.Errorf("no such service: %s", service)
+			}
+			services = append(services, service)
+		}
+		options.Project = restrictServices(options.Project, services)
+		containers = containers.replicas(options.ServiceReplicas, isDownService)
+		partial = true
+	}
+
+	if options.DesiredStateFile != "" {
+		absent, err := absentServices(options.DesiredStateFile, options.Project)
+		if err != nil {
+			return nil, false, err
+		}
+		options.Project = restrictServices(options.Project, absent)
+		containers = containers.filter(isDownService(absent...))
+		partial = true
+	}
+
+	if options.OlderThan > 0 {
+		var kept bool
+		containers, kept = restrictRemovable(options, containers, isDownService, isCreatedBefore(time.Now().Add(-options.OlderThan)))
+		partial = partial || kept
+	}
+
+	if len(options.KeepStates) > 0 {
+		var kept bool
+		containers, kept = restrictRemovable(options, containers, isDownService, isNotInState(options.KeepStates...))
+		partial = partial || kept
+	}
+	return containers, partial, nil
+}
+
+// restrictRemovable narrows containers to the ones matching the predicate, reporting whether containers which would
+// have been removed otherwise are kept
+func restrictRemovable(options *compose.DownOptions, containers Containers, isDownService func(services ...string) containerPredicate,
+	remove containerPredicate) (Containers, bool) {
+	removable := containers
+	if !options.RemoveOrphans {
+		removable = containers.filter(isDownService(options.Project.ServiceNames()...))
+	}
+	kept := len(removable.filter(remove)) < len(removable)
+	return containers.filter(remove), kept
+}
+
+// removeProjectContainers removes service containers in reverse dependency order, then orphans if requested
+func (s *composeService) removeProjectContainers(ctx context.Context, w progress.Writer, containers Containers, isDownService func(services ...string) containerPredicate,
+	options compose.DownOptions, summary *downSummary, inspector *inspectCache) error {
+	project := options.Project
+	if options.InferVolumeOrder {
+		project = withVolumeOrdering(project)
+	}
+	project = servicesWithContainers(project, containers, isDownService)
+	if options.MemoryOrder {
+		var err error
+		containers, err = s.removeInMemoryOrder(ctx, w, project, containers, isDownService, options, summary, inspector)
+		if err != nil || !options.RemoveOrphans {
+			return err
+		}
+		_, err = s.removeServiceContainers(ctx, w, "", containers, options, summary, inspector)
+		return err
+	}
+	var mtx sync.Mutex
+	err := InReverseDependencyOrder(ctx, project, func(c context.Context, service types.ServiceConfig) error {
+		mtx.Lock()
+		serviceContainers, others := containers.split(isDownService(service.Name))
 		containers = others
+		mtx.Unlock()
+		serviceOptions, remove, err := s.decideServiceTeardown(ctx, w, service, serviceContainers, options, summary)
+		if err != nil || !remove {
+			return err
+		}
+		removed, err := s.removeServiceContainers(ctx, w, service.Name, serviceContainers, serviceOptions, summary, inspector)
+		if len(serviceContainers) > 0 {
+			w.Event(serviceRemovedEvent(service.Name, removed, len(serviceContainers)))
+		}
+		if err == nil && options.Placeholders && len(serviceContainers) > 0 {
+			err = s.createPlaceholder(ctx, w, project.Name, service.Name, serviceContainers[0])
+		}
 		return err
 	})
 
 	if options.RemoveOrphans {
-		err := s.removeContainers(ctx, w, eg, containers)
+		_, err := s.removeServiceContainers(ctx, w, "", containers, options, summary, inspector)
 		if err != nil {
 			return err
 		}
 	}
+	return err
+}
+
+// cleanupProject removes the resources shared by the project once all its containers are gone
+func (s *composeService) cleanupProject(ctx context.Context, projectName string, projectContainers Containers, images []string,
+	options compose.DownOptions, summary *downSummary) error {
+	if options.ReportReclaimedSpace {
+		s.collectReclaimableSizes(ctx, projectName, images, options, summary)
+	}
 
+	done := downMetricsFromContext(ctx).phase(phaseResources)
+	networks, err := s.removeNetworksAndVolumes(ctx, projectName, options, summary)
+	done()
 	if err != nil {
 		return err
 	}
-	err = eg.Wait()
+
+	if options.CheckFirewallRules {
+		s.checkFirewallRules(ctx, networks, summary)
+	}
+
+	if options.VerifySubnetsReleased {
+		s.verifySubnetsReleased(ctx, options.Project, networks, summary)
+	}
+
+	if options.CheckHeldPorts {
+		checkHeldPorts(s.apiClient.DaemonHost(), projectContainers.publishedPorts(), summary)
+	}
+
+	err = s.removeProjectImages(ctx, projectName, images, options, summary)
 	if err != nil {
 		return err
 	}
+
+	if options.ReportReclaimedSpace {
+		reportReclaimedSpace(ctx, summary)
+	}
+
+	if options.Tombstone {
+		err = s.createTombstone(ctx, projectName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.removeProjectFiles(ctx, projectName, projectContainers, options); err != nil {
+		return err
+	}
+	s.forgetProject(ctx, projectName)
+	return nil
+}
+
+// removeProjectFiles removes the files compose stored on the host for the project containers, as requested
+func (s *composeService) removeProjectFiles(ctx context.Context, projectName string, projectContainers Containers, options compose.DownOptions) error {
+	if options.RemoveContextMetadata {
+		return s.removeContextMetadata(ctx, projectName, projectContainers)
+	}
+	return nil
+}
+
+// removeProjectImages archives, removes and prunes project images as requested
+func (s *composeService) removeProjectImages(ctx context.Context, projectName string, images []string, options compose.DownOptions, summary *downSummary) error {
+	if options.ArchiveImagesPrefix != "" {
+		err := s.archiveImages(ctx, options.Project, options.ArchiveImagesPrefix, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(images) > 0 {
+		err := s.removeImages(ctx, images, options, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.PruneDangling {
+		return s.pruneDanglingImages(ctx, projectName, options, summary)
+	}
+	return nil
+}
+
+// removeNetworksAndVolumes removes the project networks, and volumes if requested, in the configured order
+func (s *composeService) removeNetworksAndVolumes(ctx context.Context, projectName string, options compose.DownOptions, summary *downSummary) ([]moby.NetworkResource, error) {
+	var networks []moby.NetworkResource
+	removeNetworks := func() error {
+		var err error
+		networks, err = s.removeNetworks(ctx, projectName, options, summary)
+		return err
+	}
+	removeVolumes := func() error {
+		if !options.Volumes {
+			return nil
+		}
+		return s.removeVolumes(ctx, projectName, options, summary)
+	}
+
+	if options.ResourceOrder == compose.Concurrently {
+		return networks, removeConcurrently(removeNetworks, removeVolumes)
+	}
+
+	steps := []func() error{removeNetworks, removeVolumes}
+	if options.ResourceOrder == compose.VolumesFirst {
+		steps = []func() error{removeVolumes, removeNetworks}
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return networks, err
+		}
+	}
+	return networks, nil
+}
+
+// removeConcurrently runs independent removals at the same time, each applying its own concurrency control, and
+// aggregates their errors
+func removeConcurrently(removals ...func() error) error {
+	errs := make([]error, len(removals))
+	var wg sync.WaitGroup
+	for i, removal := range removals {
+		i, removal := i, removal
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = removal()
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	default:
+		return multierror.Append(failed[0], failed[1:]...)
+	}
+}
+
+// removeNetworks removes the project networks, and returns them
+func (s *composeService) removeNetworks(ctx context.Context, projectName string, options compose.DownOptions, summary *downSummary) ([]moby.NetworkResource, error) {
 	networks, err := s.apiClient.NetworkList(ctx, moby.NetworkListOptions{
 		Filters: filters.NewArgs(
 			projectFilter(projectName),
 		),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	s.reportUnlabeledDefaultNetwork(ctx, options.Project, projectName, networks)
+	networkIDs := map[string]string{}
+	var networkNames []string
 	for _, n := range networks {
-		networkID := n.ID
-		networkName := n.Name
-		eg.Go(func() error {
-			return s.ensureNetworkDown(ctx, networkID, networkName)
-		})
+		networkIDs[n.Name] = n.ID
+		networkNames = append(networkNames, n.Name)
 	}
-
-	return eg.Wait()
+	if options.NetworkGracePeriod > 0 && len(networkNames) > 0 {
+		if err := waitNetworkGracePeriod(ctx, options.NetworkGracePeriod); err != nil {
+			return networks, err
+		}
+	}
+	err = options.RemovalStrategy.RemoveNetworks(ctx, networkNames, func(ctx context.Context, name string) error {
+		ctx = withResourceID(ctx, fmt.Sprintf("Network %q", name), networkIDs[name])
+		if options.PreserveSharedDefault && name == defaultNetworkName(options.Project, projectName) {
+			shared, err := s.isSharedDefaultNetwork(ctx, options.Project, networkIDs[name])
+			if err != nil || shared {
+				return err
+			}
+		}
+		return s.ensureNetworkDown(ctx, projectName, networkIDs[name], name, summary)
+	})
+	return networks, err
 }
 
-func (s *composeService) stopContainers(ctx context.Context, w progress.Writer, containers []moby.Container) error {
+// stopContainers stops containers one after the other. When maxStop is set, a stop not returning in time, typically as
+// the daemon is unresponsive, is abandoned with a warning rather than blocking the teardown
+func (s *composeService) stopContainers(ctx context.Context, w progress.Writer, containers []moby.Container, maxStop time.Duration) error {
 	for _, container := range containers {
 		toStop := container
 		eventName := "Container " + getCanonicalContainerName(toStop)
 		w.Event(progress.StoppingEvent(eventName))
-		err := s.apiClient.ContainerStop(ctx, toStop.ID, nil)
+		abandoned, err := s.stopContainerWithin(ctx, toStop.ID, maxStop)
+		if abandoned {
+			logrus.Warnf("container %s did not stop within %s, moving on", getCanonicalContainerName(toStop), maxStop)
+			w.Event(progress.NewEvent(eventName, progress.Done, "Stop abandoned"))
+			continue
+		}
 		if err != nil {
 			w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping"))
 			return err
@@ -109,29 +596,140 @@ func (s *composeService) stopContainers(ctx context.Context, w progress.Writer,
 }
 
 func (s *composeService) removeContainers(ctx context.Context, w progress.Writer, eg *errgroup.Group, containers []moby.Container) error {
+	inspector := newInspectCache(s.apiClient)
 	for _, container := range containers {
 		toDelete := container
 		eg.Go(func() error {
-			eventName := "Container " + getCanonicalContainerName(toDelete)
-			w.Event(progress.StoppingEvent(eventName))
-			err := s.stopContainers(ctx, w, []moby.Container{container})
-			if err != nil {
-				w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
-				return err
-			}
-			w.Event(progress.RemovingEvent(eventName))
-			err = s.apiClient.ContainerRemove(ctx, toDelete.ID, moby.ContainerRemoveOptions{Force: true})
-			if err != nil {
-				w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
-				return err
-			}
-			w.Event(progress.RemovedEvent(eventName))
-			return nil
+			return s.removeContainer(ctx, w, toDelete, compose.DownOptions{}, nil, inspector)
 		})
 	}
 	return eg.Wait()
 }
 
+// removeServiceContainers removes containers of a service according to the removal strategy, and returns the number of removed containers
+func (s *composeService) removeServiceContainers(ctx context.Context, w progress.Writer, service string, containers Containers,
+	options compose.DownOptions, summary *downSummary, inspector *inspectCache) (int, error) {
+	byName := map[string]moby.Container{}
+	for _, c := range containers {
+		byName[getCanonicalContainerName(c)] = c
+	}
+	var removed int32
+	err := options.RemovalStrategy.RemoveContainers(ctx, service, containers.names(), func(ctx context.Context, name string) error {
+		container, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("no container %s for service %q", name, service)
+		}
+		if err := s.removeContainer(ctx, w, container, options, summary, inspector); err != nil {
+			return err
+		}
+		atomic.AddInt32(&removed, 1)
+		return nil
+	})
+	return int(atomic.LoadInt32(&removed)), err
+}
+
+// serviceRemovedEvent rolls up the removal of a service's containers
+func serviceRemovedEvent(service string, removed int, total int) progress.Event {
+	status := progress.Done
+	if removed < total {
+		status = progress.Error
+	}
+	return progress.NewEvent("Service "+service, status, fmt.Sprintf("%d/%d containers removed", removed, total))
+}
+
+func (s *composeService) removeContainer(ctx context.Context, w progress.Writer, container moby.Container, options compose.DownOptions, summary *downSummary, inspector *inspectCache) error {
+	eventName := "Container " + getCanonicalContainerName(container)
+	w = resourceIDWriter{Writer: w, eventID: eventName, resourceID: container.ID}
+	ctx = progress.WithContextWriter(ctx, w)
+	if options.Drain != nil {
+		err := s.drainContainer(ctx, w, container, *options.Drain)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Draining"))
+			return err
+		}
+	}
+	if options.CollectStats {
+		s.collectStats(ctx, container, summary)
+	}
+	if options.StopCheck != nil {
+		err := s.checkReadyToStop(ctx, w, container, *options.StopCheck)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping"))
+			return err
+		}
+	}
+	w.Event(progress.StoppingEvent(eventName))
+	err := s.disableRestartPolicy(ctx, container, inspector)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping"))
+		return err
+	}
+	err = s.stopContainers(ctx, w, []moby.Container{container}, options.MaxStopDuration)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
+		return err
+	}
+	if options.WaitStopped {
+		err = s.waitStopped(ctx, container, options.MaxStopDuration)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping"))
+			return err
+		}
+	}
+	if options.RemoveCheckpoints {
+		err = s.removeCheckpoints(ctx, w, eventName, container)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
+			return err
+		}
+	}
+	if options.CommitBeforeRemove {
+		err = s.commitContainer(ctx, w, container, summary)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
+			return err
+		}
+	}
+	w.Event(progress.RemovingEvent(eventName))
+	err = s.apiClient.ContainerRemove(ctx, container.ID, moby.ContainerRemoveOptions{
+		Force:         true,
+		RemoveVolumes: options.Volumes,
+	})
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
+		return err
+	}
+	w.Event(progress.RemovedEvent(eventName))
+	summary.containerRemoved(container.ID)
+	return nil
+}
+
+// restrictServices returns a copy of the project with only the named services
+func restrictServices(project *types.Project, services []string) *types.Project {
+	restricted := *project
+	restricted.Services = nil
+	for _, service := range project.Services {
+		if contains(services, service.Name) {
+			restricted.Services = append(restricted.Services, service)
+		}
+	}
+	return &restricted
+}
+
+// downProject returns the compose model to tear down, which is reconstructed from container labels when not set by options
+func (s *composeService) downProject(ctx context.Context, projectName string, options compose.DownOptions) (*types.Project, error) {
+	if options.Project != nil {
+		return options.Project, nil
+	}
+	if options.ProjectFile != "" {
+		return projectFromFile(projectName, options.ProjectFile)
+	}
+	if options.ServiceResolver != nil {
+		return s.projectFromResolvedServices(ctx, projectName, options.ServiceResolver)
+	}
+	return s.projectFromContainerLabels(ctx, projectName)
+}
+
 func (s *composeService) projectFromContainerLabels(ctx context.Context, projectName string) (*types.Project, error) {
 	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
 		Filters: filters.NewArgs(
@@ -148,6 +746,11 @@ func (s *composeService) projectFromContainerLabels(ctx context.Context, project
 	if len(containers) == 0 {
 		return fakeProject, nil
 	}
+	if newer, ok := newerSchemaVersion(containers); ok {
+		logrus.Warnf("project %s was created with a newer compose model format (%s), only its services are reconstructed "+
+			"from container labels", projectName, newer)
+		return projectFromServiceLabels(projectName, containers), nil
+	}
 	options, err := loadProjectOptionsFromLabels(containers[0])
 	if err != nil {
 		return nil, err
@@ -168,6 +771,44 @@ func (s *composeService) projectFromContainerLabels(ctx context.Context, project
 	return project, nil
 }
 
+// projectFromResolvedServices reconstructs a compose model with the services containers are mapped to by the resolver
+func (s *composeService) projectFromResolvedServices(ctx context.Context, projectName string, resolve compose.ServiceResolver) (*types.Project, error) {
+	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+		All:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	project := &types.Project{Name: projectName}
+	var services []string
+	for _, c := range containers {
+		service := resolve(getCanonicalContainerName(c), c.Labels)
+		if service != "" && !contains(services, service) {
+			services = append(services, service)
+			project.Services = append(project.Services, types.ServiceConfig{Name: service})
+		}
+	}
+	return project, nil
+}
+
+func projectFromFile(projectName string, file string) (*types.Project, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, errors.Wrapf(err, "invalid project file %s", file)
+	}
+	options, err := cli.NewProjectOptions([]string{file},
+		cli.WithOsEnv,
+		cli.WithName(projectName))
+	if err != nil {
+		return nil, err
+	}
+	project, err := cli.ProjectFromOptions(options)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load project file %s", file)
+	}
+	return project, nil
+}
+
 func loadProjectOptionsFromLabels(c moby.Container) (*cli.ProjectOptions, error) {
 	var configFiles []string
 	relativePathConfigFiles := strings.Split(c.Labels[configFilesLabel], ",")
@@ -0,0 +1,493 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func testProject(name string, services ...string) *types.Project {
+	project := &types.Project{Name: name}
+	for _, service := range services {
+		project.Services = append(project.Services, types.ServiceConfig{Name: service})
+	}
+	return project
+}
+
+func TestDown(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownDryRunThenExecute(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	network := testNetwork("p", "default")
+	network.Containers["other"] = moby.EndpointResource{Name: "other"}
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:           testProject("p", "web", "db"),
+		DryRunThenExecute: true,
+	})
+	assert.ErrorContains(t, err, "network p_default can't be removed: in use by container other")
+	assert.Equal(t, len(api.containers), 2)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestDownDryRunThenExecuteOrphans(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "orphan", 1)}
+	network := testNetwork("p", "default")
+	network.Containers["p_web_1"] = moby.EndpointResource{Name: "p_web_1"}
+	network.Containers["p_orphan_1"] = moby.EndpointResource{Name: "p_orphan_1"}
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:           testProject("p", "web"),
+		DryRunThenExecute: true,
+	})
+	assert.ErrorContains(t, err, "in use by container p_orphan_1")
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:           testProject("p", "web"),
+		DryRunThenExecute: true,
+		RemoveOrphans:     true,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownProjectFile(t *testing.T) {
+	dir := fs.NewDir(t, "export", fs.WithFile("exported.yaml", `
+services:
+  web:
+    image: nginx
+    depends_on:
+      - db
+  db:
+    image: mysql
+`))
+	defer dir.Remove()
+
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{ProjectFile: dir.Join("exported.yaml")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_1", "p_db_1"})
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownInvalidProjectFile(t *testing.T) {
+	dir := fs.NewDir(t, "export", fs.WithFile("exported.yaml", "services: [web]"))
+	defer dir.Remove()
+	tested := composeService{apiClient: newFakeClient()}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{ProjectFile: dir.Join("exported.yaml")})
+	assert.ErrorContains(t, err, "failed to load project file")
+
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{ProjectFile: dir.Join("missing.yaml")})
+	assert.ErrorContains(t, err, "invalid project file")
+}
+
+func TestDownHealthConditionOrder(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "db", 1), testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "db"},
+			{
+				Name: "web",
+				DependsOn: map[string]types.ServiceDependency{
+					"db": {Condition: "service_healthy"},
+				},
+			},
+		},
+	}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project})
+	assert.NilError(t, err)
+	webRemoved := api.callIndex("ContainerRemove", "p_web_1")
+	dbStopped := api.callIndex("ContainerStop", "p_db_1")
+	assert.Assert(t, webRemoved >= 0 && dbStopped >= 0)
+	assert.Assert(t, webRemoved < dbStopped, "web should be removed before db is stopped")
+}
+
+func TestDownServiceGroup(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.Labels["group"] = "frontend"
+	front := testContainer("p", "api", 1)
+	front.Labels["group"] = "frontend"
+	db := testContainer("p", "db", 1)
+	db.Labels["group"] = "backend"
+	api.containers = []moby.Container{db, front, web}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", DependsOn: map[string]types.ServiceDependency{"api": {}}},
+			{Name: "api", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+			{Name: "db"},
+		},
+	}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:           project,
+		ServiceGroupLabel: "group",
+		ServiceGroup:      "frontend",
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_1", "p_api_1"})
+	assert.DeepEqual(t, api.containers, []moby.Container{db})
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownServiceGroupRequired(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), ServiceGroupLabel: "group"})
+	assert.ErrorContains(t, err, "service group label requires a service group")
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestDownMatchServiceByName(t *testing.T) {
+	unlabeled := moby.Container{
+		ID:     "p_web_2",
+		Names:  []string{"/p_web_2"},
+		State:  "running",
+		Labels: map[string]string{projectLabel: "p"},
+	}
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+			{Name: "db"},
+		},
+	}
+
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "db", 1), unlabeled}
+	tested := composeService{apiClient: api}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.containers, []moby.Container{unlabeled})
+
+	api = newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "db", 1), unlabeled}
+	tested = composeService{apiClient: api}
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, MatchServiceByName: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_2", "p_db_1"})
+}
+
+func TestDownServiceRollUpEvents(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "web", 3), testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web", "db", "cache")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, w.statusOf("Service web"), []string{"3/3 containers removed"})
+	assert.DeepEqual(t, w.statusOf("Service db"), []string{"1/1 containers removed"})
+	assert.Equal(t, len(w.statusOf("Service cache")), 0)
+}
+
+func TestDownServiceRollUpEventOnFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	api.errors["ContainerRemove p_web_2"] = fmt.Errorf("device or resource busy")
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.ErrorContains(t, err, "device or resource busy")
+	assert.DeepEqual(t, w.statusOf("Service web"), []string{"1/2 containers removed"})
+	for _, e := range w.events {
+		if e.ID == "Service web" {
+			assert.Equal(t, e.Status, progress.Error)
+		}
+	}
+}
+
+func TestDownServiceResolver(t *testing.T) {
+	api := newFakeClient()
+	imported := func(name string, app string) moby.Container {
+		return moby.Container{
+			ID:     name,
+			Names:  []string{"/" + name},
+			State:  "running",
+			Labels: map[string]string{projectLabel: "p", "app.kubernetes.io/name": app},
+		}
+	}
+	api.containers = []moby.Container{imported("frontend-7d9f", "frontend"), imported("backend-5c2a", "backend"), imported("backend-9b1e", "backend")}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "backend"},
+			{Name: "frontend", DependsOn: map[string]types.ServiceDependency{"backend": {}}},
+		},
+	}
+
+	err := tested.Down(ctx, "p", compose.DownOptions{
+		Project: project,
+		ServiceResolver: func(container string, labels map[string]string) string {
+			return labels["app.kubernetes.io/name"]
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Assert(t, api.callIndex("ContainerRemove", "frontend-7d9f") < api.callIndex("ContainerStop", "backend-5c2a"))
+	assert.Assert(t, api.callIndex("ContainerRemove", "frontend-7d9f") < api.callIndex("ContainerStop", "backend-9b1e"))
+	assert.DeepEqual(t, w.statusOf("Service backend"), []string{"2/2 containers removed"})
+	assert.DeepEqual(t, w.statusOf("Service frontend"), []string{"1/1 containers removed"})
+}
+
+func TestDownServiceResolverWithoutProject(t *testing.T) {
+	api := newFakeClient()
+	worker := moby.Container{ID: "worker-1", Names: []string{"/worker-1"}, Labels: map[string]string{projectLabel: "p", "role": "worker"}}
+	api.containers = []moby.Container{worker}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{
+		ServiceResolver: func(container string, labels map[string]string) string {
+			return labels["role"]
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.DeepEqual(t, w.statusOf("Service worker"), []string{"1/1 containers removed"})
+}
+
+func TestDownServiceReplicas(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "web", 3),
+		testContainer("p", "worker", 1), testContainer("p", "worker", 2),
+		testContainer("p", "db", 1),
+	}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web", "worker", "db"),
+		ServiceReplicas: map[string][]int{"web": {2, 3}, "worker": {2}},
+		Volumes:         true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.containers, []moby.Container{testContainer("p", "web", 1), testContainer("p", "worker", 1), testContainer("p", "db", 1)})
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.volumes), 1)
+}
+
+func TestDownServiceReplicasUnknownService(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		ServiceReplicas: map[string][]int{"api": {1}},
+	})
+	assert.ErrorContains(t, err, "no such service: api")
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestDownMaskEvents(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("secret", "web", 1), testContainer("secret", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("secret", "default")}
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "secret", compose.DownOptions{
+		Project:    testProject("secret", "web", "db"),
+		MaskEvents: true,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+
+	assert.Assert(t, len(w.events) > 0)
+	for _, e := range w.events {
+		for _, text := range []string{e.ID, e.ParentID, e.Text, e.StatusText} {
+			assert.Assert(t, !strings.Contains(text, "secret"), "unmasked event %+v", e)
+			assert.Assert(t, !strings.Contains(text, "web"), "unmasked event %+v", e)
+		}
+	}
+	assert.DeepEqual(t, w.statusOf("Network 1"), []string{"Removing", "Removed"})
+	assert.Assert(t, len(w.statusOf("Container 1")) > 0)
+	assert.Assert(t, len(w.statusOf("Container 2")) > 0)
+}
+
+func agedContainer(project string, service string, number int, age time.Duration) moby.Container {
+	c := testContainer(project, service, number)
+	c.Created = time.Now().Add(-age).Unix()
+	return c
+}
+
+func TestDownOlderThan(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		agedContainer("p", "web", 1, 3*time.Hour),
+		agedContainer("p", "web", 2, time.Minute),
+		agedContainer("p", "db", 1, 3*time.Hour),
+	}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), OlderThan: time.Hour})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 2)
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, api.containers[0].ID, "p_web_2")
+	// network is still used by the recent container
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownOlderThanAllStale(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{agedContainer("p", "web", 1, 3*time.Hour), agedContainer("p", "db", 1, 2*time.Hour)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), OlderThan: time.Hour})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownOlderThanWithOrphans(t *testing.T) {
+	containers := func() []moby.Container {
+		return []moby.Container{
+			agedContainer("p", "web", 1, 3*time.Hour),
+			agedContainer("p", "legacy", 1, 3*time.Hour),
+			agedContainer("p", "fresh", 1, time.Minute),
+		}
+	}
+
+	// recent orphans are kept, and so is the network they use
+	api := newFakeClient()
+	api.containers = containers()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), OlderThan: time.Hour, RemoveOrphans: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 2)
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, api.containers[0].ID, "p_fresh_1")
+	assert.Equal(t, len(api.networks), 1)
+
+	// recent orphans which are not removed don't prevent the project teardown
+	api = newFakeClient()
+	api.containers = containers()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested = composeService{apiClient: api}
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), OlderThan: time.Hour})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_1"})
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default"})
+}
+
+func exitedContainer(project string, service string, number int) moby.Container {
+	c := testContainer(project, service, number)
+	c.State = "exited"
+	return c
+}
+
+func TestDownKeepStates(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1),
+		exitedContainer("p", "web", 2),
+		exitedContainer("p", "db", 1),
+	}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), KeepStates: []string{"running"}, Volumes: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 2)
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, api.containers[0].ID, "p_web_1")
+	// network and volumes are still used by the running container
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.volumes), 1)
+}
+
+func TestDownKeepStatesNoneKept(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{exitedContainer("p", "web", 1), exitedContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), KeepStates: []string{"running", "paused"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownKeepStatesInvalid(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), KeepStates: []string{"up"}})
+	assert.ErrorContains(t, err, `invalid container state "up"`)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
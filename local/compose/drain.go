@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// drainContainer notifies a running container it is about to be stopped, then waits for the grace period
+func (s *composeService) drainContainer(ctx context.Context, w progress.Writer, container moby.Container, drain compose.DrainOptions) error {
+	if container.State != "running" {
+		return nil
+	}
+	eventName := "Container " + getCanonicalContainerName(container)
+	w.Event(progress.NewEvent(eventName, progress.Working, "Draining"))
+	if len(drain.Command) > 0 {
+		exec, err := s.apiClient.ContainerExecCreate(ctx, container.ID, moby.ExecConfig{
+			Cmd:    drain.Command,
+			Detach: true,
+		})
+		if err != nil {
+			return err
+		}
+		err = s.apiClient.ContainerExecStart(ctx, exec.ID, moby.ExecStartCheck{Detach: true})
+		if err != nil {
+			return err
+		}
+	}
+	if drain.Signal != "" {
+		err := s.apiClient.ContainerKill(ctx, container.ID, drain.Signal)
+		if err != nil {
+			return err
+		}
+	}
+	if drain.GracePeriod <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(drain.GracePeriod)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
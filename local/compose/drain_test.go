@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownDrainCommandBeforeStop(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Command: []string{"touch", "/tmp/drain"}},
+	})
+	assert.NilError(t, err)
+	for _, name := range []string{"p_web_1", "p_web_2"} {
+		drained := api.callIndex("ContainerExecStart", "exec_"+name)
+		stopped := api.callIndex("ContainerStop", name)
+		assert.Assert(t, drained >= 0 && drained < stopped, "%s should be drained before it is stopped", name)
+	}
+}
+
+func TestDownDrainSignalWaitsGracePeriod(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	start := time.Now()
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Signal: "SIGUSR1", GracePeriod: 50 * time.Millisecond},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, time.Since(start) >= 50*time.Millisecond)
+	killed := api.callIndex("ContainerKill", "p_web_1")
+	assert.Assert(t, killed >= 0 && killed < api.callIndex("ContainerStop", "p_web_1"))
+}
+
+func TestDownDrainSkipsStoppedContainers(t *testing.T) {
+	api := newFakeClient()
+	stopped := testContainer("p", "web", 1)
+	stopped.State = "exited"
+	api.containers = []moby.Container{stopped}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Signal: "SIGUSR1"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerKill")), 0)
+}
+
+func TestDownDrainFailureKeepsContainer(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerKill p_web_1"] = fmt.Errorf("cannot kill container")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Signal: "SIGUSR1"},
+	})
+	assert.ErrorContains(t, err, "cannot kill container")
+	assert.Equal(t, len(api.callsTo("ContainerStop")), 0)
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestDownDrainCommandAndSignalExclusive(t *testing.T) {
+	tested := composeService{apiClient: newFakeClient()}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		Drain:   &compose.DrainOptions{Command: []string{"true"}, Signal: "SIGUSR1"},
+	})
+	assert.ErrorContains(t, err, "mutually exclusive")
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// isActiveEndpointsError checks a network removal failed because containers are still connected to it. Other forbidden
+// removals, like predefined networks, are not
+func isActiveEndpointsError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "has active endpoints")
+}
+
+// disconnectLeftoverEndpoints force disconnects the project containers still connected to a network, typically
+// containers which didn't fully remove, so that the network can be removed. Containers of other projects sharing the
+// network are left connected
+func (s *composeService) disconnectLeftoverEndpoints(ctx context.Context, projectName string, networkID string, eventName string) error {
+	w := progress.ContextWriter(ctx)
+	network, err := s.apiClient.NetworkInspect(ctx, networkID, moby.NetworkInspectOptions{})
+	if err != nil {
+		return err
+	}
+	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+		All:     true,
+	})
+	if err != nil {
+		return err
+	}
+	projectContainers := map[string]bool{}
+	for _, c := range containers {
+		projectContainers[c.ID] = true
+	}
+	for id, endpoint := range network.Containers {
+		name := endpoint.Name
+		if name == "" {
+			name = id
+		}
+		if !projectContainers[id] {
+			logrus.Warnf("endpoint %s on network %s doesn't belong to project %s, it will not be disconnected", name, network.Name, projectName)
+			continue
+		}
+		w.Event(progress.NewEvent(eventName, progress.Working, fmt.Sprintf("Disconnecting leftover endpoint %s", name)))
+		if err := s.apiClient.NetworkDisconnect(ctx, networkID, id, true); err != nil && !errdefs.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to disconnect leftover endpoint %s", name)
+		}
+	}
+	w.Event(progress.RemovingEvent(eventName))
+	return nil
+}
+
+// swarmServices returns the swarm services attached to a network. Their tasks endpoints are managed by swarm and must
+// not be disconnected
+func (s *composeService) swarmServices(ctx context.Context, networkID string) ([]string, error) {
+	network, err := s.apiClient.NetworkInspect(ctx, networkID, moby.NetworkInspectOptions{Verbose: true})
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for name := range network.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services, nil
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	network_api "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// leftoverContainer is a container of the project the model doesn't know about, still connected to the project network
+func leftoverContainer(id string) moby.Container {
+	c := testContainer("p", "legacy", 1)
+	c.ID = id
+	return c
+}
+
+func TestDownDisconnectsLeftoverEndpoint(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), leftoverContainer("9f3c2a")}
+	network := testNetwork("p", "default")
+	network.Containers["p_web_1"] = moby.EndpointResource{Name: "p_web_1"}
+	network.Containers["9f3c2a"] = moby.EndpointResource{Name: "p_web_1_dead"}
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, api.callsTo("NetworkDisconnect"), []string{"9f3c2a"})
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default", "p_default"})
+	assert.DeepEqual(t, w.statusOf(`Network "p_default"`), []string{
+		"Removing",
+		"Disconnecting leftover endpoint p_web_1_dead",
+		"Removing",
+		"Removed",
+	})
+}
+
+func TestDownLeftoverEndpointDisconnectFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{leftoverContainer("9f3c2a")}
+	network := testNetwork("p", "default")
+	network.Containers["9f3c2a"] = moby.EndpointResource{Name: "stuck"}
+	api.networks = []moby.NetworkResource{network}
+	api.errors["NetworkDisconnect 9f3c2a"] = fmt.Errorf("container is not responding")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.ErrorContains(t, err, "failed to disconnect leftover endpoint stuck: container is not responding")
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownKeepsEndpointsOfOtherProjects(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("other", "app", 1)}
+	network := testNetwork("p", "default")
+	network.Containers["other_app_1"] = moby.EndpointResource{Name: "other_app_1"}
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.ErrorContains(t, err, "has active endpoints")
+	assert.Equal(t, len(api.callsTo("NetworkDisconnect")), 0)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestIsActiveEndpointsError(t *testing.T) {
+	assert.Assert(t, isActiveEndpointsError(errdefs.Forbidden(fmt.Errorf("error while removing network: network p_default id 1f2e has active endpoints"))))
+	assert.Assert(t, !isActiveEndpointsError(errdefs.Forbidden(fmt.Errorf("bridge is a pre-defined network and cannot be removed"))))
+	assert.Assert(t, !isActiveEndpointsError(nil))
+}
+
+func TestDownSkipsNetworkUsedBySwarmServices(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	network := testNetwork("p", "default")
+	network.Scope = "swarm"
+	network.Containers["4b1d8e"] = moby.EndpointResource{Name: "monitoring_agent.1.x7k2p9"}
+	network.Services = map[string]network_api.ServiceInfo{
+		"monitoring_agent": {Tasks: []network_api.Task{{Name: "monitoring_agent.1.x7k2p9"}}},
+	}
+	api.networks = []moby.NetworkResource{network}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: testProject("p", "web"), Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, result.Networks, 0)
+	// swarm tasks endpoints are left alone
+	assert.Equal(t, len(api.callsTo("NetworkDisconnect")), 0)
+	assert.DeepEqual(t, w.statusOf(`Network "p_default"`), []string{"Removing", "Kept, in use by swarm services"})
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func (s *composeService) EstimateDown(ctx context.Context, projectName string, options compose.DownOptions) (*compose.DownEstimate, error) {
+	project, err := s.downProject(ctx, projectName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers Containers
+	containers, err = s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(project.Name)),
+		All:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	options.Project = project
+	isDownService := downServiceMatcher(options)
+	containers, partial, err := restrictDownContainers(&options, containers, isDownService)
+	if err != nil {
+		return nil, err
+	}
+	if !options.RemoveOrphans {
+		containers = containers.filter(isDownService(options.Project.ServiceNames()...))
+	}
+
+	estimate := &compose.DownEstimate{Containers: len(containers)}
+	// a partial teardown keeps the resources shared by the project
+	if partial {
+		return estimate, nil
+	}
+
+	networks, err := s.apiClient.NetworkList(ctx, moby.NetworkListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	estimate.Networks = len(networks)
+	if !options.Volumes {
+		return estimate, nil
+	}
+
+	estimate.Volumes, estimate.VolumesSize, err = s.estimateVolumes(ctx, projectName, options.VolumeDrivers)
+	if err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// estimateVolumes counts the project volumes using one of the drivers, any if none is set, and their size
+func (s *composeService) estimateVolumes(ctx context.Context, projectName string, drivers []string) (int, int64, error) {
+	volumes, err := s.apiClient.VolumeList(ctx, filters.NewArgs(projectFilter(projectName)))
+	if err != nil {
+		return 0, 0, err
+	}
+	var selected []string
+	for _, v := range volumes.Volumes {
+		// same selection as the volumes removal, the driver is already listed
+		if len(drivers) == 0 || contains(drivers, v.Driver) {
+			selected = append(selected, v.Name)
+		}
+	}
+	if len(selected) == 0 {
+		return 0, 0, nil
+	}
+
+	sizes, err := s.volumeSizes(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	for _, name := range selected {
+		size += sizes[name]
+	}
+	return len(selected), size, nil
+}
+
+// volumeSizes collects volumes disk usage by name. VolumeInspect doesn't report usage, only disk usage does
+func (s *composeService) volumeSizes(ctx context.Context) (map[string]int64, error) {
+	usage, err := s.apiClient.DiskUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[string]int64{}
+	for _, v := range usage.Volumes {
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			sizes[v.Name] = v.UsageData.Size
+		}
+	}
+	return sizes, nil
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestEstimateDown(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "orphan", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default"), testNetwork("p", "back"), testNetwork("other", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024), testVolume("p", "cache", 512), testVolume("other", "data", 2048)}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *estimate, compose.DownEstimate{
+		Containers:  2,
+		Networks:    2,
+		Volumes:     2,
+		VolumesSize: 1536,
+	})
+
+	estimate, err = tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveOrphans: true})
+	assert.NilError(t, err)
+	assert.Equal(t, estimate.Containers, 3)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestEstimateDownWithoutVolumes(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *estimate, compose.DownEstimate{Containers: 1})
+	assert.Equal(t, len(api.callsTo("DiskUsage")), 0)
+}
+
+func TestEstimateDownKeptVolumes(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024)}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *estimate, compose.DownEstimate{Networks: 1})
+	assert.Equal(t, len(api.callsTo("DiskUsage")), 0)
+}
+
+func TestEstimateDownPartial(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024)}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		Volumes:         true,
+		ServiceReplicas: map[string][]int{"web": {2}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, estimate.Networks, 0)
+	assert.Equal(t, estimate.Volumes, 0)
+}
+
+func TestEstimateDownKeepStates(t *testing.T) {
+	api := newFakeClient()
+	running := testContainer("p", "web", 1)
+	running.State = "running"
+	exited := testContainer("p", "web", 2)
+	exited.State = "exited"
+	api.containers = []moby.Container{running, exited}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), KeepStates: []string{"running"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *estimate, compose.DownEstimate{Containers: 1})
+}
+
+func TestEstimateDownVolumeDrivers(t *testing.T) {
+	api := newFakeClient()
+	remote := testVolume("p", "remote", 2048)
+	remote.Driver = "nfs"
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024), remote}
+	tested := composeService{apiClient: api}
+
+	estimate, err := tested.EstimateDown(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true, VolumeDrivers: []string{"local"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *estimate, compose.DownEstimate{Volumes: 1, VolumesSize: 1024})
+}
@@ -0,0 +1,49 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// downRecordingEvents runs the teardown while recording its progress events. Events are recorded once masked, if
+// requested, so that recordings can be shared. As recording is a debugging aid, failing to record doesn't fail the teardown
+func (s *composeService) downRecordingEvents(ctx context.Context, projectName string, options compose.DownOptions) error {
+	path := options.RecordEventsTo
+	options.RecordEventsTo = ""
+	// unless masked, events reveal resource names and errors of the teardown
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to record events to %s", path)
+	}
+	recorder := progress.NewEventRecorder(progress.ContextWriter(ctx), f)
+	err = s.Down(progress.WithContextWriter(ctx, recorder), projectName, options)
+	if recordErr := recorder.Err(); recordErr != nil {
+		logrus.Warnf("failed to record events to %s: %v", path, recordErr)
+	}
+	if closeErr := f.Close(); closeErr != nil {
+		logrus.Warnf("failed to record events to %s: %v", path, closeErr)
+	}
+	return err
+}
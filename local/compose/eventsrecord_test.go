@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownRecordEventsThenReplay(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	dir := fs.NewDir(t, "events")
+	defer dir.Remove()
+	path := dir.Join("events.jsonl")
+
+	live := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), live), "p", compose.DownOptions{Project: testProject("p", "web"), RecordEventsTo: path})
+	assert.NilError(t, err)
+	assert.Assert(t, len(live.events) > 0)
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close() //nolint:errcheck
+	replayed := &recordingWriter{}
+	err = progress.Replay(f, replayed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, replayed.events, live.events, cmpopts.IgnoreUnexported(progress.Event{}))
+}
+
+func TestDownRecordEventsMasked(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("secret", "web", 1)}
+	tested := composeService{apiClient: api}
+	dir := fs.NewDir(t, "events")
+	defer dir.Remove()
+	path := dir.Join("events.jsonl")
+
+	err := tested.Down(context.TODO(), "secret", compose.DownOptions{Project: testProject("secret", "web"), RecordEventsTo: path, MaskEvents: true})
+	assert.NilError(t, err)
+	recording, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, len(recording) > 0)
+	assert.Assert(t, !strings.Contains(string(recording), "secret"))
+}
+
+func TestDownRecordEventsUnwritable(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	dir := fs.NewDir(t, "events")
+	defer dir.Remove()
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RecordEventsTo: dir.Join("missing", "events.jsonl")})
+	assert.ErrorContains(t, err, "failed to record events to")
+	// nothing is removed when events can't be recorded
+	assert.Equal(t, len(api.containers), 1)
+}
@@ -0,0 +1,811 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// fakeClient is an in-memory docker engine, supporting the subset of the API used by compose
+type fakeClient struct {
+	client.APIClient
+
+	mtx        sync.Mutex
+	containers []moby.Container
+	networks   []moby.NetworkResource
+	images     []moby.ImageSummary
+	volumes    []moby.Volume
+	logs       map[string]string
+	restarts   map[string]string
+	// leaked are the volumes the engine fails to remove along with containers
+	leaked map[string]bool
+	calls  []string
+	errors map[string]error
+	// transient are the number of times calls fail with their error before succeeding
+	transient map[string]int
+	// exitDelays are the time containers take to exit once stopped
+	exitDelays map[string]time.Duration
+	// subscribers receive the destroy events of containers matching their filters
+	subscribers []eventSubscriber
+	// checkpoints are the checkpoints names by container ID
+	checkpoints map[string][]string
+	// readOnly rejects mutating calls, as an authorization plugin would
+	readOnly bool
+	// stats are the resource usage snapshots by container ID, statsDelays the time they take to be sampled
+	stats       map[string]moby.StatsJSON
+	statsDelays map[string]time.Duration
+	// execFailures are the number of times commands exit with status 1 in a container before they succeed
+	execFailures  map[string]int
+	execExitCodes map[string]int
+	// hostConfigs are the host configurations containers are inspected with, by container ID
+	hostConfigs map[string]container.HostConfig
+	// stopDelays are the time container stops take to return, as with an unresponsive daemon
+	stopDelays map[string]time.Duration
+	// daemonHost is the address the engine is reached at
+	daemonHost string
+}
+
+// mutatingCalls are the calls a read-only engine rejects
+var mutatingCalls = []string{
+	"ContainerStart", "ContainerStop", "ContainerKill", "ContainerRemove", "ContainerUpdate", "ContainerCreate", "ContainerCommit", "ContainerExecCreate",
+	"NetworkRemove", "NetworkDisconnect", "NetworksPrune", "VolumeRemove", "ImageRemove", "ImageTag", "ImagesPrune",
+	"CheckpointDelete",
+}
+
+type eventSubscriber struct {
+	filters  filters.Args
+	messages chan events.Message
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		errors:        map[string]error{},
+		logs:          map[string]string{},
+		restarts:      map[string]string{},
+		leaked:        map[string]bool{},
+		transient:     map[string]int{},
+		exitDelays:    map[string]time.Duration{},
+		checkpoints:   map[string][]string{},
+		stats:         map[string]moby.StatsJSON{},
+		statsDelays:   map[string]time.Duration{},
+		execFailures:  map[string]int{},
+		execExitCodes: map[string]int{},
+		hostConfigs:   map[string]container.HostConfig{},
+		stopDelays:    map[string]time.Duration{},
+		daemonHost:    "unix:///var/run/docker.sock",
+	}
+}
+
+func (f *fakeClient) DaemonHost() string {
+	return f.daemonHost
+}
+
+func (f *fakeClient) record(call string, id string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.calls = append(f.calls, call+" "+id)
+	if f.readOnly && contains(mutatingCalls, call) {
+		return errdefs.Forbidden(fmt.Errorf("authorization denied by plugin readonly: %s is not allowed", call))
+	}
+	if n, ok := f.transient[call+" "+id]; ok {
+		if n == 0 {
+			return nil
+		}
+		f.transient[call+" "+id] = n - 1
+	}
+	return f.errors[call+" "+id]
+}
+
+func (f *fakeClient) callsTo(call string) []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var ids []string
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, call+" ") {
+			ids = append(ids, strings.TrimPrefix(c, call+" "))
+		}
+	}
+	return ids
+}
+
+// callIndex returns the position of a call in the recorded calls, or -1
+func (f *fakeClient) callIndex(call string, id string) int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, c := range f.calls {
+		if c == call+" "+id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *fakeClient) ContainerList(ctx context.Context, options moby.ContainerListOptions) ([]moby.Container, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var list []moby.Container
+	for _, c := range f.containers {
+		if !options.All && c.State != "running" {
+			continue
+		}
+		if matchLabels(options.Filters, c.Labels) {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) ContainerInspect(ctx context.Context, id string) (moby.ContainerJSON, error) {
+	if err := f.record("ContainerInspect", id); err != nil {
+		return moby.ContainerJSON{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, c := range f.containers {
+		if c.ID == id {
+			hostConfig := f.hostConfigs[c.ID]
+			hostConfig.RestartPolicy = container.RestartPolicy{Name: f.restarts[c.ID]}
+			return moby.ContainerJSON{
+				ContainerJSONBase: &moby.ContainerJSONBase{
+					ID:    c.ID,
+					Name:  c.Names[0],
+					Image: c.ImageID,
+					State: &moby.ContainerState{
+						Status:  c.State,
+						Running: c.State == "running",
+					},
+					HostConfig: &hostConfig,
+				},
+			}, nil
+		}
+	}
+	return moby.ContainerJSON{}, errdefs.NotFound(fmt.Errorf("no such container %s", id))
+}
+
+func (f *fakeClient) ContainerLogs(ctx context.Context, id string, options moby.ContainerLogsOptions) (io.ReadCloser, error) {
+	if err := f.record("ContainerLogs", id); err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var buf bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(f.logs[id]))
+	return ioutil.NopCloser(&buf), err
+}
+
+func (f *fakeClient) ContainerStats(ctx context.Context, id string, stream bool) (moby.ContainerStats, error) {
+	if err := f.record("ContainerStats", id); err != nil {
+		return moby.ContainerStats{}, err
+	}
+	f.mtx.Lock()
+	stats, delay := f.stats[id], f.statsDelays[id]
+	f.mtx.Unlock()
+	select {
+	case <-ctx.Done():
+		return moby.ContainerStats{}, ctx.Err()
+	case <-time.After(delay):
+	}
+	body, err := json.Marshal(stats)
+	return moby.ContainerStats{Body: ioutil.NopCloser(bytes.NewReader(body)), OSType: "linux"}, err
+}
+
+func (f *fakeClient) ContainerCommit(ctx context.Context, id string, options moby.ContainerCommitOptions) (moby.IDResponse, error) {
+	if err := f.record("ContainerCommit", id); err != nil {
+		return moby.IDResponse{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.images = append(f.images, moby.ImageSummary{ID: "sha256:" + id, RepoTags: []string{options.Reference}})
+	return moby.IDResponse{ID: "sha256:" + id}, nil
+}
+
+func (f *fakeClient) ContainerExecCreate(ctx context.Context, id string, config moby.ExecConfig) (moby.IDResponse, error) {
+	if err := f.record("ContainerExecCreate", id); err != nil {
+		return moby.IDResponse{}, err
+	}
+	return moby.IDResponse{ID: "exec_" + id}, nil
+}
+
+func (f *fakeClient) ContainerExecStart(ctx context.Context, execID string, config moby.ExecStartCheck) error {
+	if err := f.record("ContainerExecStart", execID); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	id := strings.TrimPrefix(execID, "exec_")
+	f.execExitCodes[execID] = 0
+	if f.execFailures[id] > 0 {
+		f.execFailures[id]--
+		f.execExitCodes[execID] = 1
+	}
+	return nil
+}
+
+func (f *fakeClient) ContainerExecInspect(ctx context.Context, execID string) (moby.ContainerExecInspect, error) {
+	if err := f.record("ContainerExecInspect", execID); err != nil {
+		return moby.ContainerExecInspect{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return moby.ContainerExecInspect{
+		ExecID:      execID,
+		ContainerID: strings.TrimPrefix(execID, "exec_"),
+		ExitCode:    f.execExitCodes[execID],
+	}, nil
+}
+
+func (f *fakeClient) ContainerKill(ctx context.Context, id string, signal string) error {
+	return f.record("ContainerKill", id)
+}
+
+func (f *fakeClient) ContainerUpdate(ctx context.Context, id string, config container.UpdateConfig) (container.ContainerUpdateOKBody, error) {
+	if err := f.record("ContainerUpdate", id); err != nil {
+		return container.ContainerUpdateOKBody{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.restarts[id] = config.RestartPolicy.Name
+	return container.ContainerUpdateOKBody{}, nil
+}
+
+func (f *fakeClient) ContainerStop(ctx context.Context, id string, timeout *time.Duration) error {
+	if err := f.record("ContainerStop", id); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	delay, ok := f.stopDelays[id]
+	f.mtx.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func (f *fakeClient) ContainerStart(ctx context.Context, id string, options moby.ContainerStartOptions) error {
+	if err := f.record("ContainerStart", id); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, c := range f.containers {
+		if c.ID == id {
+			f.containers[i].State = "running"
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) ContainerWait(ctx context.Context, id string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error) {
+	result := make(chan container.ContainerWaitOKBody, 1)
+	errs := make(chan error, 1)
+	if err := f.record("ContainerWait", id+" "+string(condition)); err != nil {
+		errs <- err
+		return result, errs
+	}
+	f.mtx.Lock()
+	delay := f.exitDelays[id]
+	f.mtx.Unlock()
+	go func() {
+		select {
+		case <-time.After(delay):
+			_ = f.record("ContainerExited", id)
+			result <- container.ContainerWaitOKBody{}
+		case <-ctx.Done():
+			errs <- ctx.Err()
+		}
+	}()
+	return result, errs
+}
+
+func (f *fakeClient) ContainerRemove(ctx context.Context, id string, options moby.ContainerRemoveOptions) error {
+	if err := f.record("ContainerRemove", id); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, c := range f.containers {
+		if c.ID == id {
+			f.containers = append(f.containers[:i], f.containers[i+1:]...)
+			for _, n := range f.networks {
+				delete(n.Containers, id)
+			}
+			if options.RemoveVolumes {
+				f.removeAnonymousVolumes(c)
+			}
+			f.publish(events.Message{Type: events.ContainerEventType, Action: "destroy", Actor: events.Actor{ID: c.ID, Attributes: c.Labels}})
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("no such container %s", id))
+}
+
+func (f *fakeClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	if err := f.record("ContainerCreate", containerName); err != nil {
+		return container.ContainerCreateCreatedBody{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, c := range f.containers {
+		if c.Names[0] == "/"+containerName {
+			return container.ContainerCreateCreatedBody{}, errdefs.Conflict(fmt.Errorf("container name %q is already in use", containerName))
+		}
+	}
+	f.containers = append(f.containers, moby.Container{
+		ID:     containerName,
+		Names:  []string{"/" + containerName},
+		Image:  config.Image,
+		Labels: config.Labels,
+		State:  "created",
+	})
+	return container.ContainerCreateCreatedBody{ID: containerName}, nil
+}
+
+func (f *fakeClient) CheckpointList(ctx context.Context, container string, options moby.CheckpointListOptions) ([]moby.Checkpoint, error) {
+	if err := f.record("CheckpointList", container); err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var checkpoints []moby.Checkpoint
+	for _, name := range f.checkpoints[container] {
+		checkpoints = append(checkpoints, moby.Checkpoint{Name: name})
+	}
+	return checkpoints, nil
+}
+
+func (f *fakeClient) CheckpointDelete(ctx context.Context, container string, options moby.CheckpointDeleteOptions) error {
+	if err := f.record("CheckpointDelete", container+" "+options.CheckpointID); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var kept []string
+	for _, name := range f.checkpoints[container] {
+		if name != options.CheckpointID {
+			kept = append(kept, name)
+		}
+	}
+	if len(kept) == len(f.checkpoints[container]) {
+		return errdefs.NotFound(fmt.Errorf("no such checkpoint %s", options.CheckpointID))
+	}
+	f.checkpoints[container] = kept
+	return nil
+}
+
+func (f *fakeClient) Events(ctx context.Context, options moby.EventsOptions) (<-chan events.Message, <-chan error) {
+	errs := make(chan error, 1)
+	if err := f.record("Events", ""); err != nil {
+		errs <- err
+		return nil, errs
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	messages := make(chan events.Message, 100)
+	f.subscribers = append(f.subscribers, eventSubscriber{filters: options.Filters, messages: messages})
+	return messages, errs
+}
+
+// publish sends a message to subscribers, dropping it for the ones not keeping up
+func (f *fakeClient) publish(message events.Message) {
+	for _, s := range f.subscribers {
+		if !matchLabels(s.filters, message.Actor.Attributes) {
+			continue
+		}
+		select {
+		case s.messages <- message:
+		default:
+		}
+	}
+}
+
+func (f *fakeClient) NetworkList(ctx context.Context, options moby.NetworkListOptions) ([]moby.NetworkResource, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var list []moby.NetworkResource
+	for _, n := range f.networks {
+		if matchLabels(options.Filters, n.Labels) {
+			list = append(list, n)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) NetworkInspect(ctx context.Context, id string, options moby.NetworkInspectOptions) (moby.NetworkResource, error) {
+	if err := f.record("NetworkInspect", id); err != nil {
+		return moby.NetworkResource{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, n := range f.networks {
+		if n.ID == id || n.Name == id {
+			return n, nil
+		}
+	}
+	return moby.NetworkResource{}, errdefs.NotFound(fmt.Errorf("no such network %s", id))
+}
+
+func (f *fakeClient) NetworkRemove(ctx context.Context, id string) error {
+	if err := f.record("NetworkRemove", id); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, n := range f.networks {
+		if n.ID == id {
+			if len(n.Containers) > 0 {
+				return errdefs.Forbidden(fmt.Errorf("error while removing network: network %s id %s has active endpoints", n.Name, n.ID))
+			}
+			f.networks = append(f.networks[:i], f.networks[i+1:]...)
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("no such network %s", id))
+}
+
+func (f *fakeClient) NetworkDisconnect(ctx context.Context, id string, container string, force bool) error {
+	if err := f.record("NetworkDisconnect", container); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, n := range f.networks {
+		if n.ID == id {
+			delete(n.Containers, container)
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("no such network %s", id))
+}
+
+func (f *fakeClient) ImageTag(ctx context.Context, source string, target string) error {
+	if err := f.record("ImageTag", source); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	i := f.imageIndex(source)
+	if i < 0 {
+		return errdefs.NotFound(fmt.Errorf("no such image: %s", source))
+	}
+	f.images[i].RepoTags = append(f.images[i].RepoTags, target)
+	return nil
+}
+
+func (f *fakeClient) ImageRemove(ctx context.Context, id string, options moby.ImageRemoveOptions) ([]moby.ImageDeleteResponseItem, error) {
+	if err := f.record("ImageRemove", id); err != nil {
+		return nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	i := f.imageIndex(id)
+	if i < 0 {
+		return nil, errdefs.NotFound(fmt.Errorf("no such image: %s", id))
+	}
+	if f.imageInUse(f.images[i].ID) {
+		return nil, errdefs.Conflict(fmt.Errorf("conflict: unable to remove repository reference %q", id))
+	}
+	var tags []string
+	for _, tag := range f.images[i].RepoTags {
+		if tag != id && tag != id+":latest" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		f.images[i].RepoTags = tags
+		return []moby.ImageDeleteResponseItem{{Untagged: id}}, nil
+	}
+	deleted := f.images[i].ID
+	f.images = append(f.images[:i], f.images[i+1:]...)
+	return []moby.ImageDeleteResponseItem{{Untagged: id}, {Deleted: deleted}}, nil
+}
+
+// imageIndex finds an image by ID or tag, latest being the default tag
+func (f *fakeClient) imageIndex(ref string) int {
+	for i, image := range f.images {
+		if image.ID == ref {
+			return i
+		}
+		for _, tag := range image.RepoTags {
+			if tag == ref || tag == ref+":latest" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (f *fakeClient) ImageInspectWithRaw(ctx context.Context, id string) (moby.ImageInspect, []byte, error) {
+	if err := f.record("ImageInspectWithRaw", id); err != nil {
+		return moby.ImageInspect{}, nil, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	i := f.imageIndex(id)
+	if i < 0 {
+		return moby.ImageInspect{}, nil, errdefs.NotFound(fmt.Errorf("no such image: %s", id))
+	}
+	image := f.images[i]
+	return moby.ImageInspect{ID: image.ID, RepoTags: image.RepoTags, Size: image.Size, Config: &container.Config{Labels: image.Labels}}, nil, nil
+}
+
+func (f *fakeClient) ImageList(ctx context.Context, options moby.ImageListOptions) ([]moby.ImageSummary, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var list []moby.ImageSummary
+	for _, image := range f.images {
+		if contains(options.Filters.Get("dangling"), "true") && len(image.RepoTags) > 0 {
+			continue
+		}
+		if matchLabels(options.Filters, image.Labels) {
+			list = append(list, image)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) NetworksPrune(ctx context.Context, args filters.Args) (moby.NetworksPruneReport, error) {
+	if err := f.record("NetworksPrune", args.Get("label")[0]); err != nil {
+		return moby.NetworksPruneReport{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	report := moby.NetworksPruneReport{}
+	var kept []moby.NetworkResource
+	for _, n := range f.networks {
+		if len(n.Containers) == 0 && matchLabels(args, n.Labels) {
+			report.NetworksDeleted = append(report.NetworksDeleted, n.Name)
+			continue
+		}
+		kept = append(kept, n)
+	}
+	f.networks = kept
+	return report, nil
+}
+
+func (f *fakeClient) ImagesPrune(ctx context.Context, args filters.Args) (moby.ImagesPruneReport, error) {
+	if err := f.record("ImagesPrune", args.Get("label")[0]); err != nil {
+		return moby.ImagesPruneReport{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	report := moby.ImagesPruneReport{}
+	var kept []moby.ImageSummary
+	for _, image := range f.images {
+		if len(image.RepoTags) == 0 && matchLabels(args, image.Labels) && !f.imageInUse(image.ID) {
+			report.ImagesDeleted = append(report.ImagesDeleted, moby.ImageDeleteResponseItem{Deleted: image.ID})
+			report.SpaceReclaimed += uint64(image.Size)
+			continue
+		}
+		kept = append(kept, image)
+	}
+	f.images = kept
+	return report, nil
+}
+
+func (f *fakeClient) imageInUse(id string) bool {
+	for _, c := range f.containers {
+		if c.ImageID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeClient) VolumeList(ctx context.Context, args filters.Args) (volume.VolumeListOKBody, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	list := volume.VolumeListOKBody{}
+	for i := range f.volumes {
+		v := f.volumes[i]
+		if matchLabels(args, v.Labels) {
+			list.Volumes = append(list.Volumes, &v)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) removeAnonymousVolumes(c moby.Container) {
+	var kept []moby.Volume
+	for _, v := range f.volumes {
+		mounted := false
+		for _, m := range c.Mounts {
+			mounted = mounted || m.Name == v.Name
+		}
+		if !mounted || len(v.Labels) > 0 || f.leaked[v.Name] {
+			kept = append(kept, v)
+		}
+	}
+	f.volumes = kept
+}
+
+func (f *fakeClient) VolumeInspect(ctx context.Context, id string) (moby.Volume, error) {
+	if err := f.record("VolumeInspect", id); err != nil {
+		return moby.Volume{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, v := range f.volumes {
+		if v.Name == id {
+			return v, nil
+		}
+	}
+	return moby.Volume{}, errdefs.NotFound(fmt.Errorf("no such volume: %s", id))
+}
+
+func (f *fakeClient) VolumeCreate(ctx context.Context, options volume.VolumeCreateBody) (moby.Volume, error) {
+	if err := f.record("VolumeCreate", options.Name); err != nil {
+		return moby.Volume{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, v := range f.volumes {
+		if v.Name == options.Name {
+			return v, nil
+		}
+	}
+	v := moby.Volume{Name: options.Name, Driver: options.Driver, Labels: options.Labels}
+	f.volumes = append(f.volumes, v)
+	return v, nil
+}
+
+func (f *fakeClient) VolumeRemove(ctx context.Context, id string, force bool) error {
+	if err := f.record("VolumeRemove", id); err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, v := range f.volumes {
+		if v.Name == id {
+			f.volumes = append(f.volumes[:i], f.volumes[i+1:]...)
+			return nil
+		}
+	}
+	return errdefs.NotFound(fmt.Errorf("no such volume: %s", id))
+}
+
+func (f *fakeClient) DiskUsage(ctx context.Context) (moby.DiskUsage, error) {
+	if err := f.record("DiskUsage", ""); err != nil {
+		return moby.DiskUsage{}, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	usage := moby.DiskUsage{}
+	for i := range f.volumes {
+		v := f.volumes[i]
+		usage.Volumes = append(usage.Volumes, &v)
+	}
+	return usage, nil
+}
+
+func matchLabels(args filters.Args, labels map[string]string) bool {
+	for _, filter := range args.Get("label") {
+		kv := strings.SplitN(filter, "=", 2)
+		value, ok := labels[kv[0]]
+		if !ok || len(kv) == 2 && value != kv[1] {
+			return false
+		}
+	}
+	return true
+}
+
+func testContainer(project string, service string, number int) moby.Container {
+	name := fmt.Sprintf("%s_%s_%d", project, service, number)
+	return moby.Container{
+		ID:    name,
+		Names: []string{"/" + name},
+		State: "running",
+		Labels: map[string]string{
+			projectLabel:         project,
+			serviceLabel:         service,
+			containerNumberLabel: fmt.Sprint(number),
+		},
+	}
+}
+
+func testVolume(project string, name string, size int64) moby.Volume {
+	return moby.Volume{
+		Name:   project + "_" + name,
+		Driver: "local",
+		Labels: map[string]string{
+			projectLabel: project,
+			volumeLabel:  name,
+		},
+		UsageData: &moby.VolumeUsageData{Size: size, RefCount: 1},
+	}
+}
+
+func testNetwork(project string, name string) moby.NetworkResource {
+	return moby.NetworkResource{
+		ID:   project + "_" + name,
+		Name: project + "_" + name,
+		Labels: map[string]string{
+			projectLabel: project,
+			networkLabel: name,
+		},
+		Containers: map[string]moby.EndpointResource{},
+	}
+}
+
+// recordingWriter is a progress.Writer collecting all events
+type recordingWriter struct {
+	mtx    sync.Mutex
+	events []progress.Event
+}
+
+func (w *recordingWriter) Start(context.Context) error {
+	return nil
+}
+
+func (w *recordingWriter) Stop() {
+}
+
+func (w *recordingWriter) Event(e progress.Event) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.events = append(w.events, e)
+}
+
+func (w *recordingWriter) resourceIDsOf(id string) []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	var ids []string
+	for _, e := range w.events {
+		if e.ID == id {
+			ids = append(ids, e.ResourceID)
+		}
+	}
+	return ids
+}
+
+func (w *recordingWriter) statusOf(id string) []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	var status []string
+	for _, e := range w.events {
+		if e.ID == id {
+			status = append(status, e.StatusText)
+		}
+	}
+	return status
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// firewallInspector lists the rules of the host firewall
+type firewallInspector interface {
+	Rules(ctx context.Context) ([]string, error)
+}
+
+// iptablesInspector lists rules from all iptables tables
+type iptablesInspector struct{}
+
+func (iptablesInspector) Rules(ctx context.Context) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "iptables-save").Output()
+	if err != nil {
+		return nil, err
+	}
+	var rules []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "-A ") {
+			rules = append(rules, line)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// checkFirewallRules reports firewall rules referring to the subnets of removed networks. Those are only reported,
+// as removing firewall rules automatically could weaken the host security
+func (s *composeService) checkFirewallRules(ctx context.Context, networks []moby.NetworkResource, summary *downSummary) {
+	var subnets []string
+	for _, n := range networks {
+		for _, config := range n.IPAM.Config {
+			if config.Subnet != "" {
+				subnets = append(subnets, config.Subnet)
+			}
+		}
+	}
+	if len(subnets) == 0 {
+		return
+	}
+
+	inspector := s.firewall
+	if inspector == nil {
+		inspector = iptablesInspector{}
+	}
+	rules, err := inspector.Rules(ctx)
+	if err != nil {
+		logrus.Warnf("unable to check firewall rules: %v", err)
+		return
+	}
+	for _, rule := range rules {
+		for _, subnet := range subnets {
+			if ruleRefersTo(rule, subnet) {
+				logrus.Warnf("firewall rule for removed network subnet %s still exists: %s", subnet, rule)
+				summary.staleFirewallRule(rule)
+				break
+			}
+		}
+	}
+}
+
+// ruleRefersTo tells whether a rule matches the subnet as its source or destination. Arguments are compared whole, as
+// 10.0.0.0/8 would otherwise be found in 110.0.0.0/8
+func ruleRefersTo(rule string, subnet string) bool {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "-s", "--source", "-d", "--destination":
+			if fields[i+1] == subnet {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+type fakeFirewall struct {
+	rules []string
+}
+
+func (f fakeFirewall) Rules(ctx context.Context) ([]string, error) {
+	return f.rules, nil
+}
+
+func TestDownCheckFirewallRules(t *testing.T) {
+	api := newFakeClient()
+	n := testNetwork("p", "default")
+	n.IPAM = network.IPAM{Config: []network.IPAMConfig{{Subnet: "172.18.0.0/16"}}}
+	api.networks = []moby.NetworkResource{n}
+	stale := "-A POSTROUTING -s 172.18.0.0/16 ! -o br-1234 -j MASQUERADE"
+	tested := composeService{
+		apiClient: api,
+		firewall: fakeFirewall{rules: []string{
+			"-A POSTROUTING -s 172.17.0.0/16 ! -o docker0 -j MASQUERADE",
+			stale,
+		}},
+	}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p"), CheckFirewallRules: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, result.StaleFirewallRules, []string{stale})
+}
+
+func TestDownCheckFirewallRulesClean(t *testing.T) {
+	api := newFakeClient()
+	n := testNetwork("p", "default")
+	n.IPAM = network.IPAM{Config: []network.IPAMConfig{{Subnet: "172.18.0.0/16"}}}
+	api.networks = []moby.NetworkResource{n}
+	tested := composeService{
+		apiClient: api,
+		firewall:  fakeFirewall{rules: []string{"-A POSTROUTING -s 172.17.0.0/16 ! -o docker0 -j MASQUERADE"}},
+	}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p"), CheckFirewallRules: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.StaleFirewallRules), 0)
+}
+
+func TestRuleRefersTo(t *testing.T) {
+	assert.Assert(t, ruleRefersTo("-A POSTROUTING -s 10.0.0.0/8 ! -o br-1234 -j MASQUERADE", "10.0.0.0/8"))
+	assert.Assert(t, ruleRefersTo("-A DOCKER-ISOLATION -d 10.0.0.0/8 -j DROP", "10.0.0.0/8"))
+	assert.Assert(t, !ruleRefersTo("-A POSTROUTING -s 110.0.0.0/8 ! -o br-1234 -j MASQUERADE", "10.0.0.0/8"))
+	assert.Assert(t, !ruleRefersTo("-A POSTROUTING -s 10.0.0.0/16 -j MASQUERADE", "10.0.0.0/1"))
+	assert.Assert(t, !ruleRefersTo("-A INPUT -m comment --comment 10.0.0.0/8 -j ACCEPT", "10.0.0.0/8"))
+}
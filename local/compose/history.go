@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+const (
+	historyLockTimeout = 5 * time.Second
+	// historyLockStale is the age of a lock file considered left behind by a process which didn't release it
+	historyLockStale = 30 * time.Second
+)
+
+// historyEntry is a line of the history file
+type historyEntry struct {
+	Project    string    `json:"project"`
+	Timestamp  time.Time `json:"timestamp"`
+	User       string    `json:"user"`
+	Containers int       `json:"containers"`
+	Networks   int       `json:"networks"`
+	Volumes    int       `json:"volumes"`
+	Error      string    `json:"error,omitempty"`
+	// Services are the images of the project services, by service name
+	Services map[string]string `json:"services,omitempty"`
+}
+
+// recordHistory appends the teardown to the history file. History is an audit trail and must not fail the teardown,
+// so errors are only reported as warnings
+func recordHistory(path string, projectName string, result compose.DownResult, downErr error) {
+	entry := historyEntry{
+		Project:    projectName,
+		Timestamp:  time.Now().UTC(),
+		User:       currentUser(),
+		Containers: result.Containers,
+		Networks:   result.Networks,
+		Volumes:    result.Volumes,
+		Services:   result.ServiceImages,
+	}
+	if downErr != nil {
+		entry.Error = downErr.Error()
+	}
+	if err := appendHistory(path, entry); err != nil {
+		logrus.Warnf("failed to record teardown in history file %s: %v", path, err)
+	}
+}
+
+func appendHistory(path string, entry historyEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// lockFile acquires an exclusive lock by creating a lock file, so concurrent teardowns don't interleave history lines
+func lockFile(lock string) (func(), error) {
+	deadline := time.Now().Add(historyLockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close() // nolint:errcheck
+			return func() {
+				os.Remove(lock) // nolint:errcheck
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > historyLockStale {
+			os.Remove(lock) // nolint:errcheck
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timeout waiting for lock %s", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func readHistory(t *testing.T, path string) []historyEntry {
+	content, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	var entries []historyEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry historyEntry
+		assert.NilError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestDownHistory(t *testing.T) {
+	dir := fs.NewDir(t, "history")
+	defer dir.Remove()
+	history := dir.Join(".compose", "history.jsonl")
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), HistoryFile: history})
+	assert.NilError(t, err)
+
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device or resource busy")
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), HistoryFile: history})
+	assert.ErrorContains(t, err, "device or resource busy")
+
+	entries := readHistory(t, history)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Project, "p")
+	assert.Equal(t, entries[0].User, currentUser())
+	assert.Assert(t, time.Since(entries[0].Timestamp) < time.Minute)
+	assert.Equal(t, entries[0].Containers, 2)
+	assert.Equal(t, entries[0].Networks, 1)
+	assert.Equal(t, entries[0].Error, "")
+	assert.Equal(t, entries[1].Containers, 0)
+	assert.Assert(t, strings.Contains(entries[1].Error, "device or resource busy"))
+}
+
+func TestAppendHistoryConcurrently(t *testing.T) {
+	dir := fs.NewDir(t, "history")
+	defer dir.Remove()
+	history := dir.Join("history.jsonl")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NilError(t, appendHistory(history, historyEntry{Project: fmt.Sprintf("p%d", i)}))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, len(readHistory(t, history)), 20)
+}
@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// serviceChange is how a service differs from its last recorded teardown
+type serviceChange struct {
+	service string
+	change  string
+}
+
+// serviceImages returns the images of the project services, by service name
+func serviceImages(project *types.Project) map[string]string {
+	images := map[string]string{}
+	for _, service := range project.Services {
+		images[service.Name] = service.Image
+	}
+	return images
+}
+
+// reportHistoryDiff reports how the project differs from its last teardown recorded in the history file. This is
+// informational, so failing to read the history is only reported as a warning
+func reportHistoryDiff(ctx context.Context, path string, project *types.Project) {
+	w := progress.ContextWriter(ctx)
+	previous, found, err := lastRecordedTeardown(path, project.Name)
+	if err != nil {
+		logrus.Warnf("failed to read history file %s: %v", path, err)
+		return
+	}
+	if !found {
+		w.Event(progress.NewEvent("History", progress.Done, "No previous teardown recorded"))
+		return
+	}
+	changes := historyDiff(previous.Services, serviceImages(project))
+	for _, c := range changes {
+		w.Event(progress.NewEvent("Service "+c.service, progress.Done, c.change))
+	}
+	w.Event(progress.NewEvent("History", progress.Done, fmt.Sprintf("%d change(s) since last teardown on %s", len(changes),
+		previous.Timestamp.Format(time.RFC3339))))
+}
+
+// lastRecordedTeardown returns the last teardown of a project in the history file which recorded its services
+func lastRecordedTeardown(path string, projectName string) (historyEntry, bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return historyEntry{}, false, nil
+	}
+	if err != nil {
+		return historyEntry{}, false, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	var last historyEntry
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a line could have been truncated by a crash, it doesn't invalidate the others
+			continue
+		}
+		if entry.Project == projectName && entry.Services != nil {
+			last, found = entry, true
+		}
+	}
+	return last, found, scanner.Err()
+}
+
+// historyDiff compares the images of services with the ones recorded, changes are sorted by service name
+func historyDiff(previous map[string]string, current map[string]string) []serviceChange {
+	var changes []serviceChange
+	for service, image := range current {
+		recorded, ok := previous[service]
+		switch {
+		case !ok:
+			changes = append(changes, serviceChange{service: service, change: "New since last teardown"})
+		case recorded != image:
+			changes = append(changes, serviceChange{service: service, change: fmt.Sprintf("Image changed from %s to %s since last teardown", recorded, image)})
+		}
+	}
+	for service := range previous {
+		if _, ok := current[service]; !ok {
+			changes = append(changes, serviceChange{service: service, change: "Removed since last teardown"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].service < changes[j].service
+	})
+	return changes
+}
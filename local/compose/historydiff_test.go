@@ -0,0 +1,115 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func projectWithImages(name string, images map[string]string) *types.Project {
+	project := &types.Project{Name: name}
+	for service, image := range images {
+		project.Services = append(project.Services, types.ServiceConfig{Name: service, Image: image})
+	}
+	return project
+}
+
+func TestDownDiffHistory(t *testing.T) {
+	dir := fs.NewDir(t, "history")
+	defer dir.Remove()
+	history := dir.Join("history.jsonl")
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1), testContainer("p", "cache", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:     projectWithImages("p", map[string]string{"web": "nginx:1.19", "db": "postgres:12", "cache": "redis:6"}),
+		HistoryFile: history,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, readHistory(t, history)[0].Services, map[string]string{"web": "nginx:1.19", "db": "postgres:12", "cache": "redis:6"})
+
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1), testContainer("p", "worker", 1)}
+	w := &recordingWriter{}
+	err = tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:     projectWithImages("p", map[string]string{"web": "nginx:1.21", "db": "postgres:12", "worker": "app:latest"}),
+		HistoryFile: history,
+		DiffHistory: true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, w.statusOf("Service cache"), []string{"Removed since last teardown"})
+	assert.Equal(t, w.statusOf("Service web")[0], "Image changed from nginx:1.19 to nginx:1.21 since last teardown")
+	assert.Equal(t, w.statusOf("Service worker")[0], "New since last teardown")
+	assert.Assert(t, strings.HasPrefix(w.statusOf("History")[0], "3 change(s) since last teardown on "))
+	// the diff is reported before anything is removed
+	assert.Equal(t, w.events[0].ID, "Service cache")
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownDiffHistoryWithoutPreviousTeardown(t *testing.T) {
+	dir := fs.NewDir(t, "history", fs.WithFile("history.jsonl", `{"project":"other","services":{"web":"nginx"}}
+{"project":"p","containers":2}
+{"project":"p","serv
+`))
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:     testProject("p", "web"),
+		HistoryFile: dir.Join("history.jsonl"),
+		DiffHistory: true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, w.statusOf("History"), []string{"No previous teardown recorded"})
+	assert.Equal(t, len(w.statusOf("Service web")), 1)
+}
+
+func TestDownDiffHistoryRequiresHistoryFile(t *testing.T) {
+	tested := composeService{apiClient: newFakeClient()}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), DiffHistory: true})
+	assert.ErrorContains(t, err, "history diff requires a history file")
+}
+
+func TestHistoryDiff(t *testing.T) {
+	changes := historyDiff(
+		map[string]string{"web": "nginx:1.19", "db": "postgres:12", "cache": "redis:6", "built": ""},
+		map[string]string{"web": "nginx:1.19", "db": "postgres:13", "built": "", "worker": "app"},
+	)
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.service+": "+c.change)
+	}
+	assert.DeepEqual(t, lines, []string{
+		"cache: Removed since last teardown",
+		"db: Image changed from postgres:12 to postgres:13 since last teardown",
+		"worker: New since last teardown",
+	})
+	assert.Equal(t, len(historyDiff(map[string]string{"web": "nginx"}, map[string]string{"web": "nginx"})), 0)
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// inspectCache memoizes container inspection during a teardown, so that each container is inspected at most once
+// whatever the number of features reading it. It is safe for concurrent use
+type inspectCache struct {
+	apiClient client.APIClient
+
+	mtx      sync.Mutex
+	entries  map[string]*inspectEntry
+	requests int
+}
+
+type inspectEntry struct {
+	once      sync.Once
+	container moby.ContainerJSON
+	err       error
+}
+
+func newInspectCache(apiClient client.APIClient) *inspectCache {
+	return &inspectCache{
+		apiClient: apiClient,
+		entries:   map[string]*inspectEntry{},
+	}
+}
+
+func (c *inspectCache) inspect(ctx context.Context, id string) (moby.ContainerJSON, error) {
+	c.mtx.Lock()
+	c.requests++
+	entry, ok := c.entries[id]
+	if !ok {
+		entry = &inspectEntry{}
+		c.entries[id] = entry
+	}
+	c.mtx.Unlock()
+
+	entry.once.Do(func() {
+		entry.container, entry.err = c.apiClient.ContainerInspect(ctx, id)
+	})
+	return entry.container, entry.err
+}
+
+// stats returns the number of API calls and the number of inspect requests served
+func (c *inspectCache) stats() (calls int, requests int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.entries), c.requests
+}
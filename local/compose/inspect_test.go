@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestInspectCache(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	cache := newInspectCache(api)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("p_web_%d", i%2+1)
+			inspect, err := cache.inspect(context.TODO(), id)
+			assert.NilError(t, err)
+			assert.Equal(t, inspect.ID, id)
+		}(i)
+	}
+	wg.Wait()
+
+	calls, requests := cache.stats()
+	assert.Equal(t, calls, 2)
+	assert.Equal(t, requests, 10)
+	assert.Equal(t, len(api.callsTo("ContainerInspect")), 2)
+}
+
+func TestDownInspectsContainersOnce(t *testing.T) {
+	dir := fs.NewDir(t, "inspect")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:           testProject("p", "web", "db"),
+		DiagnosticsTo:     dir.Join("diagnostics.tar.gz"),
+		DryRunThenExecute: true,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerInspect")), 3)
+}
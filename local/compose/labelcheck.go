@@ -0,0 +1,87 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// requiredLabels are the labels a project model is reconstructed from
+var requiredLabels = []string{projectLabel, serviceLabel, configFilesLabel, workingDirLabel}
+
+// projectWideLabels are the labels all containers of a project are expected to share
+var projectWideLabels = []string{configFilesLabel, workingDirLabel}
+
+// warnLabelsIntegrity warns about project containers with missing or inconsistent labels, which may indicate tampering
+// or a version mismatch and could make a future reconstruction of the project fail
+func warnLabelsIntegrity(projectName string, containers Containers) {
+	for _, issue := range labelsIntegrityIssues(projectName, containers) {
+		logrus.Warn(issue)
+	}
+}
+
+func labelsIntegrityIssues(projectName string, containers Containers) []string {
+	var issues []string
+	for _, c := range containers {
+		for _, label := range requiredLabels {
+			if _, ok := c.Labels[label]; !ok {
+				issues = append(issues, fmt.Sprintf("container %s is missing label %s", getCanonicalContainerName(c), label))
+			}
+		}
+	}
+	for _, label := range projectWideLabels {
+		expected, ok := mostCommonLabel(containers, label)
+		if !ok {
+			continue
+		}
+		for _, c := range containers {
+			if value, ok := c.Labels[label]; ok && value != expected {
+				issues = append(issues, fmt.Sprintf("container %s has label %s=%q while other containers of project %s have %q",
+					getCanonicalContainerName(c), label, value, projectName, expected))
+			}
+		}
+	}
+	return issues
+}
+
+// mostCommonLabel returns the value of a label set on most containers, the first one seen on a tie
+func mostCommonLabel(containers Containers, label string) (string, bool) {
+	counts := map[string]int{}
+	var values []string
+	for _, c := range containers {
+		value, ok := c.Labels[label]
+		if !ok {
+			continue
+		}
+		if counts[value] == 0 {
+			values = append(values, value)
+		}
+		counts[value]++
+	}
+	if len(values) == 0 {
+		return "", false
+	}
+	common := values[0]
+	for _, value := range values[1:] {
+		if counts[value] > counts[common] {
+			common = value
+		}
+	}
+	return common, true
+}
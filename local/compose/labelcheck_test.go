@@ -0,0 +1,67 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestLabelsIntegrityIssues(t *testing.T) {
+	web := labeledContainer("p", "web", 1)
+	db := labeledContainer("p", "db", 1)
+	delete(db.Labels, configFilesLabel)
+	worker := labeledContainer("p", "worker", 1)
+	worker.Labels[workingDirLabel] = "/srv/old"
+	cache := labeledContainer("p", "cache", 1)
+	delete(cache.Labels, serviceLabel)
+
+	issues := labelsIntegrityIssues("p", Containers{web, db, worker, cache})
+	assert.DeepEqual(t, issues, []string{
+		"container p_db_1 is missing label com.docker.compose.project.config_files",
+		"container p_cache_1 is missing label com.docker.compose.service",
+		`container p_worker_1 has label com.docker.compose.project.working_dir="/srv/old" while other containers of project p have "/srv/p"`,
+	})
+}
+
+func TestLabelsIntegrityConsistent(t *testing.T) {
+	issues := labelsIntegrityIssues("p", Containers{labeledContainer("p", "web", 1), labeledContainer("p", "web", 2)})
+	assert.Equal(t, len(issues), 0)
+}
+
+func TestDownValidateLabelsOnlyWarns(t *testing.T) {
+	api := newFakeClient()
+	// created by another tool, or a compose version not setting the project labels
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), ValidateLabels: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func labeledContainer(project string, service string, number int) moby.Container {
+	c := testContainer(project, service, number)
+	c.Labels[configFilesLabel] = "/srv/" + project + "/docker-compose.yml"
+	c.Labels[workingDirLabel] = "/srv/" + project
+	return c
+}
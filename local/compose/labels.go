@@ -34,9 +34,17 @@ const (
 	versionLabel         = "com.docker.compose.version"
 	configHashLabel      = "com.docker.compose.config-hash"
 	networkLabel         = "com.docker.compose.network"
+	contextLabel         = "com.docker.compose.context"
+	tombstoneLabel       = "com.docker.compose.tombstone"
+	tombstoneTimeLabel   = "com.docker.compose.tombstone.time"
+	schemaVersionLabel   = "com.docker.compose.schema-version"
 
 	//ComposeVersion Compose version
 	ComposeVersion = "1.0-alpha"
+
+	// schemaVersion is the version of the compose model format this build loads projects from labels with. It must be
+	// incremented when upgrading compose-go changes how labeled config files are interpreted
+	schemaVersion = 1
 )
 
 func projectFilter(projectName string) filters.KeyValuePair {
@@ -0,0 +1,34 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"time"
+)
+
+// stopContainerWithin stops a container, giving up once maxStop elapsed if set
+func (s *composeService) stopContainerWithin(ctx context.Context, id string, maxStop time.Duration) (bool, error) {
+	if maxStop <= 0 {
+		return false, s.apiClient.ContainerStop(ctx, id, nil)
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, maxStop)
+	defer cancel()
+	err := s.apiClient.ContainerStop(stopCtx, id, nil)
+	abandoned := err != nil && stopCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+	return abandoned, err
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownMaxStopDurationAbandonsHangingStop(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.stopDelays["p_web_1"] = time.Hour
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	start := time.Now()
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:         testProject("p", "web", "db"),
+		MaxStopDuration: 50 * time.Millisecond,
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, time.Since(start) < time.Minute)
+	// the hanging container is still force removed
+	assert.Equal(t, len(api.containers), 0)
+	assert.DeepEqual(t, w.statusOf("Container p_web_1"), []string{"Stopping", "Stopping", "Stop abandoned", "Removing", "Removed"})
+	assert.DeepEqual(t, w.statusOf("Container p_db_1"), []string{"Stopping", "Stopping", "Stopped", "Removing", "Removed"})
+}
+
+func TestDownMaxStopDurationHonorsSlowStop(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.stopDelays["p_web_1"] = 10 * time.Millisecond
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		MaxStopDuration: time.Minute,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, w.statusOf("Container p_web_1"), []string{"Stopping", "Stopping", "Stopped", "Removing", "Removed"})
+}
+
+func TestDownCancelledDuringHangingStop(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.stopDelays["p_web_1"] = time.Hour
+	tested := composeService{apiClient: api}
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+
+	err := tested.Down(ctx, "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		MaxStopDuration: time.Hour,
+	})
+	assert.ErrorContains(t, err, "context deadline exceeded")
+	assert.Equal(t, len(api.containers), 1)
+}
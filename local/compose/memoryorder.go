@@ -0,0 +1,161 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// queuedRemoval is a container waiting for its turn to be removed within a dependency tier
+type queuedRemoval struct {
+	service   string
+	container moby.Container
+	options   compose.DownOptions
+}
+
+// removeInMemoryOrder removes service containers tier by tier in reverse dependency order, one at a time within a tier
+// starting with the containers using the most memory. It returns the containers which don't belong to the services
+func (s *composeService) removeInMemoryOrder(ctx context.Context, w progress.Writer, project *types.Project, containers Containers,
+	isDownService func(services ...string) containerPredicate, options compose.DownOptions, summary *downSummary, inspector *inspectCache) (Containers, error) {
+	tiers, err := reverseDependencyTiers(project)
+	if err != nil {
+		return containers, err
+	}
+	usage := s.memoryUsages(ctx, containers)
+	for _, tier := range tiers {
+		var queue []queuedRemoval
+		total := map[string]int{}
+		for _, service := range tier {
+			var serviceContainers Containers
+			serviceContainers, containers = containers.split(isDownService(service.Name))
+			serviceOptions, remove, err := s.decideServiceTeardown(ctx, w, service, serviceContainers, options, summary)
+			if err != nil {
+				return containers, err
+			}
+			if !remove {
+				continue
+			}
+			for _, c := range serviceContainers {
+				queue = append(queue, queuedRemoval{service: service.Name, container: c, options: serviceOptions})
+			}
+			total[service.Name] = len(serviceContainers)
+		}
+		sort.SliceStable(queue, func(i, j int) bool {
+			return usage[queue[i].container.ID] > usage[queue[j].container.ID]
+		})
+		if err := s.removeQueued(ctx, w, project.Name, queue, total, options, summary, inspector); err != nil {
+			return containers, err
+		}
+	}
+	return containers, nil
+}
+
+// removeQueued removes the containers of a tier in order, then rolls up the removal of each service
+func (s *composeService) removeQueued(ctx context.Context, w progress.Writer, projectName string, queue []queuedRemoval, total map[string]int,
+	options compose.DownOptions, summary *downSummary, inspector *inspectCache) error {
+	removed := map[string]int{}
+	var err error
+	for _, r := range queue {
+		if err = s.removeContainer(ctx, w, r.container, r.options, summary, inspector); err != nil {
+			break
+		}
+		removed[r.service]++
+	}
+	for _, r := range queue {
+		if _, ok := total[r.service]; !ok {
+			continue
+		}
+		w.Event(serviceRemovedEvent(r.service, removed[r.service], total[r.service]))
+		if err == nil && options.Placeholders {
+			err = s.createPlaceholder(ctx, w, projectName, r.service, r.container)
+		}
+		delete(total, r.service)
+	}
+	return err
+}
+
+// memoryUsages samples the memory usage of running containers by ID. Containers which can't be sampled are reported as
+// using no memory, so they come last
+func (s *composeService) memoryUsages(ctx context.Context, containers Containers) map[string]uint64 {
+	var mtx sync.Mutex
+	usage := map[string]uint64{}
+	eg, _ := errgroup.WithContext(ctx)
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		container := c
+		eg.Go(func() error {
+			ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+			defer cancel()
+			stats, err := s.containerStats(ctx, container.ID)
+			if err != nil {
+				logrus.Warnf("failed to sample memory usage of container %s: %v", getCanonicalContainerName(container), err)
+				return nil
+			}
+			mtx.Lock()
+			usage[container.ID] = stats.MemoryUsage
+			mtx.Unlock()
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return usage
+}
+
+// reverseDependencyTiers groups services by the tier they are removed in, a service coming in a later tier than all the
+// services depending on it
+func reverseDependencyTiers(project *types.Project) ([]types.Services, error) {
+	g := NewGraph(project.Services, ServiceStarted)
+	if b, err := g.HasCycles(); b {
+		return nil, err
+	}
+	placed := map[string]bool{}
+	var tiers []types.Services
+	for len(placed) < len(project.Services) {
+		var tier types.Services
+		for _, service := range project.Services {
+			if !placed[service.Name] && allPlaced(g.Vertices[service.Name].Parents, placed) {
+				tier = append(tier, service)
+			}
+		}
+		for _, service := range tier {
+			placed[service.Name] = true
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+func allPlaced(vertices map[string]*Vertex, placed map[string]bool) bool {
+	for name := range vertices {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func memoryStats(usage uint64) moby.StatsJSON {
+	stats := moby.StatsJSON{}
+	stats.MemoryStats = moby.MemoryStats{Usage: usage}
+	return stats
+}
+
+func memoryOrderProject() *types.Project {
+	return &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "web", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		{Name: "api", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		{Name: "db"},
+	}}
+}
+
+func TestDownMemoryOrder(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1),
+		testContainer("p", "web", 2),
+		testContainer("p", "api", 1),
+		testContainer("p", "db", 1),
+	}
+	api.stats["p_web_1"] = memoryStats(100)
+	api.stats["p_web_2"] = memoryStats(500)
+	api.stats["p_api_1"] = memoryStats(300)
+	api.stats["p_db_1"] = memoryStats(900)
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: memoryOrderProject(), MemoryOrder: true})
+	assert.NilError(t, err)
+	// db uses the most memory, but is still removed after the services depending on it
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_2", "p_api_1", "p_web_1", "p_db_1"})
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownMemoryOrderUnsampledLast(t *testing.T) {
+	api := newFakeClient()
+	stopped := testContainer("p", "web", 2)
+	stopped.State = "exited"
+	api.containers = []moby.Container{
+		testContainer("p", "web", 1),
+		stopped,
+		testContainer("p", "api", 1),
+		testContainer("p", "db", 1),
+	}
+	api.stats["p_web_1"] = memoryStats(100)
+	api.errors["ContainerStats p_api_1"] = fmt.Errorf("connection reset")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: memoryOrderProject(), MemoryOrder: true})
+	assert.NilError(t, err)
+	assert.Equal(t, api.callsTo("ContainerRemove")[0], "p_web_1")
+	assert.Equal(t, api.callsTo("ContainerRemove")[3], "p_db_1")
+	assert.Equal(t, len(api.callsTo("ContainerStats")), 3)
+}
+
+func TestDownMemoryOrderWithRemovalStrategy(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), MemoryOrder: true, RemovalStrategy: &sequentialStrategy{}})
+	assert.ErrorContains(t, err, "memory order can't be combined with a removal strategy")
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestReverseDependencyTiers(t *testing.T) {
+	project := &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "proxy", DependsOn: map[string]types.ServiceDependency{"web": {}}},
+		{Name: "web", DependsOn: map[string]types.ServiceDependency{"db": {}, "cache": {}}},
+		{Name: "worker", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		{Name: "cache"},
+		{Name: "db"},
+	}}
+
+	tiers, err := reverseDependencyTiers(project)
+	assert.NilError(t, err)
+	var names [][]string
+	for _, tier := range tiers {
+		var tierNames []string
+		for _, service := range tier {
+			tierNames = append(tierNames, service.Name)
+		}
+		names = append(names, tierNames)
+	}
+	assert.DeepEqual(t, names, [][]string{{"proxy", "worker"}, {"web"}, {"cache", "db"}})
+}
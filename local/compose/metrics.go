@@ -0,0 +1,141 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+const (
+	phaseContainers = "containers"
+	phaseResources  = "resources"
+	phaseTotal      = "total"
+)
+
+// downMetrics collects the duration of teardown phases, it is safe for concurrent use and a nil collector is a no-op
+type downMetrics struct {
+	mtx    sync.Mutex
+	phases map[string]time.Duration
+}
+
+type downMetricsKey struct{}
+
+func withDownMetrics(ctx context.Context, metrics *downMetrics) context.Context {
+	return context.WithValue(ctx, downMetricsKey{}, metrics)
+}
+
+func downMetricsFromContext(ctx context.Context) *downMetrics {
+	metrics, _ := ctx.Value(downMetricsKey{}).(*downMetrics)
+	return metrics
+}
+
+// phase starts timing a phase, the returned func must be called once it is done. Durations of a phase run several
+// times add up
+func (m *downMetrics) phase(name string) func() {
+	if m == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		m.mtx.Lock()
+		defer m.mtx.Unlock()
+		if m.phases == nil {
+			m.phases = map[string]time.Duration{}
+		}
+		m.phases[name] += time.Since(start)
+	}
+}
+
+// prometheusText formats the teardown metrics in Prometheus text exposition format
+func (m *downMetrics) prometheusText(projectName string, result compose.DownResult, downErr error) string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	project := fmt.Sprintf("project=%q", projectName)
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "# HELP compose_down_duration_seconds Duration of the teardown phases.")
+	fmt.Fprintln(b, "# TYPE compose_down_duration_seconds gauge")
+	var phases []string
+	for phase := range m.phases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	for _, phase := range phases {
+		fmt.Fprintf(b, "compose_down_duration_seconds{%s,phase=%q} %g\n", project, phase, m.phases[phase].Seconds())
+	}
+
+	fmt.Fprintln(b, "# HELP compose_down_removed Resources removed by the teardown.")
+	fmt.Fprintln(b, "# TYPE compose_down_removed gauge")
+	fmt.Fprintf(b, "compose_down_removed{%s,resource=\"containers\"} %d\n", project, result.Containers)
+	fmt.Fprintf(b, "compose_down_removed{%s,resource=\"images\"} %d\n", project, len(result.RemovedImages))
+	fmt.Fprintf(b, "compose_down_removed{%s,resource=\"networks\"} %d\n", project, result.Networks)
+	fmt.Fprintf(b, "compose_down_removed{%s,resource=\"volumes\"} %d\n", project, result.Volumes)
+
+	failed := 0
+	if downErr != nil {
+		failed = 1
+	}
+	fmt.Fprintln(b, "# HELP compose_down_failed Whether the teardown failed.")
+	fmt.Fprintln(b, "# TYPE compose_down_failed gauge")
+	fmt.Fprintf(b, "compose_down_failed{%s} %d\n", project, failed)
+	return b.String()
+}
+
+// exportMetrics writes the teardown metrics to a file, or pushes them to a pushgateway for http(s) URLs. Metrics are
+// for observability and must not fail the teardown, so errors are only reported as warnings
+func exportMetrics(ctx context.Context, target string, projectName string, metrics *downMetrics, result compose.DownResult, downErr error) {
+	text := metrics.prometheusText(projectName, result, downErr)
+	var err error
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		err = pushMetrics(ctx, target, text)
+	} else {
+		err = ioutil.WriteFile(target, []byte(text), 0644)
+	}
+	if err != nil {
+		logrus.Warnf("failed to export teardown metrics to %s: %v", target, err)
+	}
+}
+
+func pushMetrics(ctx context.Context, url string, text string) error {
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBufferString(text))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
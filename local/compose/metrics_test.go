@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// sampleLine matches a sample of the Prometheus text exposition format
+var sampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{[a-zA-Z_][a-zA-Z0-9_]*="[^"]*"(,[a-zA-Z_][a-zA-Z0-9_]*="[^"]*")*\})? [0-9.eE+-]+$`)
+
+func assertPrometheusText(t *testing.T, text string) {
+	assert.Assert(t, strings.HasSuffix(text, "\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		assert.Assert(t, sampleLine.MatchString(line), "invalid sample %q", line)
+	}
+}
+
+func TestDownMetricsFile(t *testing.T) {
+	dir := fs.NewDir(t, "metrics")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), MetricsTo: dir.Join("down.prom")})
+	assert.NilError(t, err)
+
+	content, err := ioutil.ReadFile(dir.Join("down.prom"))
+	assert.NilError(t, err)
+	text := string(content)
+	assertPrometheusText(t, text)
+	assert.Assert(t, strings.Contains(text, "# TYPE compose_down_duration_seconds gauge\n"))
+	for _, phase := range []string{phaseContainers, phaseResources, phaseTotal} {
+		assert.Assert(t, strings.Contains(text, fmt.Sprintf("compose_down_duration_seconds{project=\"p\",phase=%q} ", phase)), phase)
+	}
+	assert.Assert(t, strings.Contains(text, "compose_down_removed{project=\"p\",resource=\"containers\"} 2\n"))
+	assert.Assert(t, strings.Contains(text, "compose_down_removed{project=\"p\",resource=\"networks\"} 1\n"))
+	assert.Assert(t, strings.Contains(text, "compose_down_failed{project=\"p\"} 0\n"))
+}
+
+func TestDownMetricsPushgateway(t *testing.T) {
+	var pushed, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		pushed = string(body)
+		contentType = r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device busy")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), MetricsTo: server.URL + "/metrics/job/compose"})
+	assert.ErrorContains(t, err, "device busy")
+	assertPrometheusText(t, pushed)
+	assert.Equal(t, contentType, "text/plain; version=0.0.4")
+	assert.Assert(t, strings.Contains(pushed, "compose_down_failed{project=\"p\"} 1\n"))
+}
+
+func TestDownMetricsExportFailureIsNotFatal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	dir := fs.NewDir(t, "metrics")
+	defer dir.Remove()
+
+	for _, target := range []string{server.URL, dir.Join("missing", "down.prom")} {
+		api := newFakeClient()
+		api.containers = []moby.Container{testContainer("p", "web", 1)}
+		tested := composeService{apiClient: api}
+
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), MetricsTo: target})
+		assert.NilError(t, err)
+		assert.Equal(t, len(api.containers), 0)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// waitNetworkGracePeriod delays the removal of networks, for in-flight DNS lookups and connections to drain
+func waitNetworkGracePeriod(ctx context.Context, grace time.Duration) error {
+	w := progress.ContextWriter(ctx)
+	eventName := "Networks"
+	w.Event(progress.NewEvent(eventName, progress.Working, fmt.Sprintf("Waiting %s for DNS propagation", grace)))
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		w.Event(progress.ErrorMessageEvent(eventName, "Grace period interrupted"))
+		return ctx.Err()
+	case <-timer.C:
+		w.Event(progress.NewEvent(eventName, progress.Done, "Grace period elapsed"))
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// cancellingWriter cancels the teardown once an event is emitted for a resource
+type cancellingWriter struct {
+	recordingWriter
+	id     string
+	cancel context.CancelFunc
+}
+
+func (w *cancellingWriter) Event(e progress.Event) {
+	w.recordingWriter.Event(e)
+	if e.ID == w.id {
+		w.cancel()
+	}
+}
+
+func TestDownNetworkGracePeriod(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	start := time.Now()
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:            testProject("p", "web"),
+		NetworkGracePeriod: 50 * time.Millisecond,
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, time.Since(start) >= 50*time.Millisecond)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, w.statusOf("Networks"), []string{"Waiting 50ms for DNS propagation", "Grace period elapsed"})
+}
+
+func TestDownNetworkGracePeriodCancelled(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	w := &cancellingWriter{id: "Networks", cancel: cancel}
+
+	start := time.Now()
+	err := tested.Down(progress.WithContextWriter(ctx, w), "p", compose.DownOptions{
+		Project:            testProject("p", "web"),
+		NetworkGracePeriod: time.Hour,
+	})
+	assert.Equal(t, err, context.Canceled)
+	assert.Assert(t, time.Since(start) < time.Minute)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 1)
+	assert.DeepEqual(t, w.statusOf("Networks"), []string{"Waiting 1h0m0s for DNS propagation", "Grace period interrupted"})
+}
+
+func TestDownNetworkGracePeriodWithoutNetworks(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:            testProject("p", "web"),
+		NetworkGracePeriod: time.Hour,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(w.statusOf("Networks")), 0)
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// desktopNotifier shows notifications on the user desktop
+type desktopNotifier interface {
+	Notify(ctx context.Context, title string, message string) error
+}
+
+// systemNotifier relies on the notification tool of the platform
+type systemNotifier struct{}
+
+func (systemNotifier) Notify(ctx context.Context, title string, message string) error {
+	return notifyCommand(ctx, title, message).Run()
+}
+
+// notifyDown notifies the user the teardown completed. Notifications are a convenience, so failing to show one is not
+// reported as an error
+func (s *composeService) notifyDown(ctx context.Context, projectName string, result compose.DownResult, err error) {
+	notifier := s.notifier
+	if notifier == nil {
+		notifier = systemNotifier{}
+	}
+	title, message := downNotification(projectName, result, err)
+	if notifyErr := notifier.Notify(ctx, title, message); notifyErr != nil {
+		logrus.Debugf("desktop notification unavailable: %v", notifyErr)
+	}
+}
+
+func downNotification(projectName string, result compose.DownResult, err error) (string, string) {
+	if err != nil {
+		return fmt.Sprintf("Failed to tear down project %s", projectName), err.Error()
+	}
+	removed := []string{
+		fmt.Sprintf("%d container(s)", result.Containers),
+		fmt.Sprintf("%d network(s)", result.Networks),
+	}
+	if result.Volumes > 0 {
+		removed = append(removed, fmt.Sprintf("%d volume(s)", result.Volumes))
+	}
+	if len(result.RemovedImages) > 0 {
+		removed = append(removed, fmt.Sprintf("%d image(s)", len(result.RemovedImages)))
+	}
+	return fmt.Sprintf("Project %s is down", projectName), "Removed " + strings.Join(removed, ", ")
+}
@@ -0,0 +1,94 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+type notification struct {
+	Title   string
+	Message string
+}
+
+type fakeNotifier struct {
+	notifications []notification
+	err           error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, title string, message string) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification{Title: title, Message: message})
+	return nil
+}
+
+func TestDownNotify(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	notifier := &fakeNotifier{}
+	tested := composeService{apiClient: api, notifier: notifier}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), Volumes: true, Notify: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, notifier.notifications, []notification{
+		{Title: "Project p is down", Message: "Removed 2 container(s), 1 network(s), 1 volume(s)"},
+	})
+}
+
+func TestDownNotifyFailure(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	notifier := &fakeNotifier{}
+	tested := composeService{apiClient: api, notifier: notifier}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Notify: true})
+	assert.ErrorContains(t, err, "network is still in use")
+	assert.Equal(t, len(notifier.notifications), 1)
+	assert.Equal(t, notifier.notifications[0].Title, "Failed to tear down project p")
+	assert.Equal(t, notifier.notifications[0].Message, err.Error())
+}
+
+func TestDownNotifierUnavailable(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api, notifier: &fakeNotifier{err: fmt.Errorf("notify-send: not found")}}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Notify: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownWithoutNotify(t *testing.T) {
+	notifier := &fakeNotifier{}
+	tested := composeService{apiClient: newFakeClient(), notifier: notifier}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(notifier.notifications), 0)
+}
@@ -0,0 +1,40 @@
+// +build windows
+
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// notifyScript shows a balloon tip, texts are read from the environment not to be interpreted as PowerShell
+const notifyScript = `Add-Type -AssemblyName System.Windows.Forms
+$icon = New-Object System.Windows.Forms.NotifyIcon
+$icon.Icon = [System.Drawing.SystemIcons]::Information
+$icon.Visible = $true
+$icon.ShowBalloonTip(5000, $env:COMPOSE_NOTIFY_TITLE, $env:COMPOSE_NOTIFY_MESSAGE, 'Info')
+Start-Sleep -Seconds 5
+$icon.Dispose()`
+
+func notifyCommand(ctx context.Context, title string, message string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", notifyScript)
+	cmd.Env = append(os.Environ(), "COMPOSE_NOTIFY_TITLE="+title, "COMPOSE_NOTIFY_MESSAGE="+message)
+	return cmd
+}
@@ -0,0 +1,77 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// placeholderLabel marks the stopped container left in place of a service, for an external reconciler to revive it from
+const placeholderLabel = "com.docker.compose.placeholder"
+
+func isNotPlaceholder(c moby.Container) bool {
+	return c.Labels[placeholderLabel] != "true"
+}
+
+func placeholderName(projectName string, service string) string {
+	return fmt.Sprintf("%s_%s_placeholder", projectName, service)
+}
+
+// createPlaceholder creates a stopped container carrying the labels of a removed service container, so that the service
+// identity survives the teardown. It is not attached to any network, not to prevent networks removal
+func (s *composeService) createPlaceholder(ctx context.Context, w progress.Writer, projectName string, service string, removed moby.Container) error {
+	name := placeholderName(projectName, service)
+	eventName := "Container " + name
+	w.Event(progress.CreatingEvent(eventName))
+
+	labels := map[string]string{}
+	for k, v := range removed.Labels {
+		switch k {
+		case containerNumberLabel, oneoffLabel, slugLabel:
+		default:
+			labels[k] = v
+		}
+	}
+	labels[placeholderLabel] = "true"
+
+	_, err := s.apiClient.ContainerCreate(ctx, &container.Config{
+		Image:  removed.Image,
+		Labels: labels,
+	}, &container.HostConfig{
+		NetworkMode: "none",
+	}, nil, nil, name)
+	switch {
+	case err == nil:
+		w.Event(progress.CreatedEvent(eventName))
+		return nil
+	case errdefs.IsConflict(err):
+		// kept from a previous teardown
+		w.Event(progress.NewEvent(eventName, progress.Done, "Exists"))
+		return nil
+	default:
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Creating"))
+		return errors.Wrapf(err, "failed to create placeholder for service %s", service)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownLeavesPlaceholders(t *testing.T) {
+	api := newFakeClient()
+	var containers []moby.Container
+	for _, c := range []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "db", 1)} {
+		c.Image = c.Labels[serviceLabel] + ":1.0"
+		c.Labels[configHashLabel] = "hash-" + c.Labels[serviceLabel]
+		containers = append(containers, c)
+	}
+	api.containers = containers
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), Placeholders: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 2)
+	assert.Equal(t, len(api.networks), 0)
+	for _, service := range []string{"web", "db"} {
+		name := placeholderName("p", service)
+		i := containerIndex(api.containers, name)
+		assert.Assert(t, i >= 0, "no placeholder for service %s", service)
+		placeholder := api.containers[i]
+		assert.Equal(t, placeholder.State, "created")
+		assert.Equal(t, placeholder.Image, service+":1.0")
+		assert.DeepEqual(t, placeholder.Labels, map[string]string{
+			projectLabel:     "p",
+			serviceLabel:     service,
+			configHashLabel:  "hash-" + service,
+			placeholderLabel: "true",
+		})
+	}
+}
+
+func TestDownKeepsExistingPlaceholder(t *testing.T) {
+	api := newFakeClient()
+	placeholder := testContainer("p", "web", 1)
+	placeholder.ID = placeholderName("p", "web")
+	placeholder.Names = []string{"/" + placeholder.ID}
+	placeholder.State = "created"
+	delete(placeholder.Labels, containerNumberLabel)
+	placeholder.Labels[placeholderLabel] = "true"
+	api.containers = []moby.Container{placeholder, testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:        testProject("p", "web"),
+		Placeholders:   true,
+		AutoRetryWhole: true,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_1"})
+	assert.DeepEqual(t, api.containers, []moby.Container{placeholder})
+	assert.DeepEqual(t, w.statusOf("Container p_web_placeholder"), []string{"Creating", "Exists"})
+}
+
+func TestDownRemovesPlaceholdersByDefault(t *testing.T) {
+	api := newFakeClient()
+	placeholder := testContainer("p", "web", 1)
+	placeholder.ID = placeholderName("p", "web")
+	placeholder.Names = []string{"/" + placeholder.ID}
+	placeholder.Labels[placeholderLabel] = "true"
+	api.containers = []moby.Container{placeholder}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.callsTo("ContainerCreate")), 0)
+}
+
+func TestUpAfterDownIgnoresPlaceholders(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.images = []moby.ImageSummary{{ID: "sha256:nginx", RepoTags: []string{"nginx:latest"}}}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Placeholders: true})
+	assert.NilError(t, err)
+
+	project := &types.Project{Name: "p", Services: types.Services{types.ServiceConfig{Name: "web", Image: "nginx", Scale: 2}}}
+	err = tested.ensureService(context.TODO(), api.containers, project, project.Services[0])
+	assert.NilError(t, err)
+	created := api.callsTo("ContainerCreate")
+	sort.Strings(created)
+	assert.DeepEqual(t, created, []string{"p_web_1", "p_web_2", placeholderName("p", "web")})
+
+	err = tested.startService(context.TODO(), project, project.Services[0])
+	assert.NilError(t, err)
+	started := api.callsTo("ContainerStart")
+	sort.Strings(started)
+	assert.DeepEqual(t, started, []string{"p_web_1", "p_web_2"})
+	assert.Equal(t, api.containers[containerIndex(api.containers, placeholderName("p", "web"))].State, "created")
+}
+
+func containerIndex(containers []moby.Container, name string) int {
+	for i, c := range containers {
+		if c.ID == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"syscall"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// publishedPorts returns the host ports published by containers, once per host address
+func (containers Containers) publishedPorts() []moby.Port {
+	seen := map[string]bool{}
+	var ports []moby.Port
+	for _, c := range containers {
+		for _, port := range c.Ports {
+			if port.PublicPort == 0 {
+				continue
+			}
+			key := portAddress(port)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+func portAddress(port moby.Port) string {
+	return fmt.Sprintf("%s/%s", net.JoinHostPort(port.IP, strconv.Itoa(int(port.PublicPort))), port.Type)
+}
+
+// checkHeldPorts reports published host ports which can't be bound once project containers are removed. Such a port
+// usually is held by a stuck userland-proxy, and the next `up` would fail with "port is already allocated". Ports are
+// probed from this host, so the check only applies to a local daemon
+func checkHeldPorts(daemonHost string, ports []moby.Port, summary *downSummary) {
+	if len(ports) == 0 {
+		return
+	}
+	if !isLocalDaemon(daemonHost) {
+		logrus.Warnf("daemon %s is not local, published host ports can't be checked", daemonHost)
+		return
+	}
+	for _, port := range ports {
+		address := portAddress(port)
+		free, err := isPortFree(port)
+		if err != nil {
+			logrus.Warnf("can't check host port %s is free: %v", address, err)
+			continue
+		}
+		if free {
+			continue
+		}
+		logrus.Warnf("host port %s is still bound after teardown, a userland-proxy may be stuck", address)
+		summary.portHeld(address)
+	}
+}
+
+// isLocalDaemon tells whether the daemon host is reached through a local socket or a loopback address
+func isLocalDaemon(daemonHost string) bool {
+	u, err := url.Parse(daemonHost)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		return true
+	case "tcp", "http", "https":
+		ip := net.ParseIP(u.Hostname())
+		return u.Hostname() == "localhost" || ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+// isPortFree attempts to bind a host port. A bind denied by permissions doesn't tell whether the port is held, and is
+// returned as an error
+func isPortFree(port moby.Port) (bool, error) {
+	address := net.JoinHostPort(port.IP, strconv.Itoa(int(port.PublicPort)))
+	var err error
+	if port.Type == "udp" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", address); err == nil {
+			_ = conn.Close()
+		}
+	} else {
+		var l net.Listener
+		if l, err = net.Listen("tcp", address); err == nil {
+			_ = l.Close()
+		}
+	}
+	if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+		return false, err
+	}
+	return err == nil, nil
+}
@@ -0,0 +1,124 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// freePort returns a host port nothing listens on
+func freePort(t *testing.T) uint16 {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	assert.NilError(t, l.Close())
+	return uint16(port)
+}
+
+func TestDownReportsHeldPorts(t *testing.T) {
+	// simulates a stuck userland-proxy
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer proxy.Close() // nolint:errcheck
+	held := uint16(proxy.Addr().(*net.TCPAddr).Port)
+	free := freePort(t)
+
+	web := testContainer("p", "web", 1)
+	web.Ports = []moby.Port{
+		{IP: "127.0.0.1", PrivatePort: 80, PublicPort: held, Type: "tcp"},
+		{IP: "127.0.0.1", PrivatePort: 443, PublicPort: free, Type: "tcp"},
+		{PrivatePort: 8080, Type: "tcp"},
+	}
+	api := newFakeClient()
+	api.containers = []moby.Container{web}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CheckHeldPorts: true, Result: &result})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.HeldPorts, []string{fmt.Sprintf("127.0.0.1:%d/tcp", held)})
+}
+
+func TestDownWithoutHeldPortsCheck(t *testing.T) {
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer proxy.Close() // nolint:errcheck
+
+	web := testContainer("p", "web", 1)
+	web.Ports = []moby.Port{{IP: "127.0.0.1", PrivatePort: 80, PublicPort: uint16(proxy.Addr().(*net.TCPAddr).Port), Type: "tcp"}}
+	api := newFakeClient()
+	api.containers = []moby.Container{web}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.HeldPorts), 0)
+}
+
+func TestPublishedPorts(t *testing.T) {
+	web1 := testContainer("p", "web", 1)
+	web1.Ports = []moby.Port{
+		{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+		{IP: "::", PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+		{IP: "0.0.0.0", PrivatePort: 53, PublicPort: 8053, Type: "udp"},
+		{PrivatePort: 9000, Type: "tcp"},
+	}
+	web2 := testContainer("p", "web", 2)
+	web2.Ports = []moby.Port{{IP: "0.0.0.0", PrivatePort: 80, PublicPort: 8080, Type: "tcp"}}
+
+	var addresses []string
+	for _, port := range (Containers{web1, web2}).publishedPorts() {
+		addresses = append(addresses, portAddress(port))
+	}
+	assert.DeepEqual(t, addresses, []string{"0.0.0.0:8080/tcp", "[::]:8080/tcp", "0.0.0.0:8053/udp"})
+}
+
+func TestDownSkipsHeldPortsCheckOfRemoteDaemon(t *testing.T) {
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer proxy.Close() // nolint:errcheck
+
+	web := testContainer("p", "web", 1)
+	web.Ports = []moby.Port{{IP: "127.0.0.1", PrivatePort: 80, PublicPort: uint16(proxy.Addr().(*net.TCPAddr).Port), Type: "tcp"}}
+	api := newFakeClient()
+	api.daemonHost = "tcp://10.0.0.2:2376"
+	api.containers = []moby.Container{web}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CheckHeldPorts: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.HeldPorts), 0)
+}
+
+func TestIsLocalDaemon(t *testing.T) {
+	assert.Assert(t, isLocalDaemon("unix:///var/run/docker.sock"))
+	assert.Assert(t, isLocalDaemon("npipe:////./pipe/docker_engine"))
+	assert.Assert(t, isLocalDaemon("tcp://127.0.0.1:2375"))
+	assert.Assert(t, isLocalDaemon("tcp://localhost:2375"))
+	assert.Assert(t, !isLocalDaemon("tcp://10.0.0.2:2376"))
+	assert.Assert(t, !isLocalDaemon("http://docker.example.com"))
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+const postDownEventName = "Post-down command"
+
+// runPostDownCommand runs the command configured to follow a successful teardown. The teardown already happened, so
+// a failing command only gets reported as a warning unless fatal is set
+func runPostDownCommand(ctx context.Context, command []string, fatal bool, projectName string, result compose.DownResult) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(postDownEventName, progress.Working, "Running"))
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"COMPOSE_PROJECT_NAME="+projectName,
+		fmt.Sprintf("COMPOSE_REMOVED_CONTAINERS=%d", result.Containers),
+		fmt.Sprintf("COMPOSE_REMOVED_NETWORKS=%d", result.Networks),
+		fmt.Sprintf("COMPOSE_REMOVED_VOLUMES=%d", result.Volumes),
+		fmt.Sprintf("COMPOSE_REMOVED_IMAGES=%d", len(result.RemovedImages)),
+	)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		w.Event(progress.NewEvent(postDownEventName, progress.Done, "Exited with code 0"))
+		return nil
+	}
+
+	status := err.Error()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		status = fmt.Sprintf("Exited with code %d", exitErr.ExitCode())
+	}
+	w.Event(progress.ErrorMessageEvent(postDownEventName, status))
+	err = errors.Wrapf(err, "post-down command %q failed: %s", strings.Join(command, " "), strings.TrimSpace(string(output)))
+	if fatal {
+		return err
+	}
+	logrus.Warn(err)
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// TestPostDownHelperProcess is the fake post-down command, it records COMPOSE_* variables to the file passed as
+// argument and exits with the given code
+func TestPostDownHelperProcess(t *testing.T) {
+	if os.Getenv("POST_DOWN_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	var env []string
+	for _, e := range os.Environ() {
+		if strings.HasPrefix(e, "COMPOSE_") {
+			env = append(env, e)
+		}
+	}
+	_ = ioutil.WriteFile(args[1], []byte(strings.Join(env, "\n")), 0644)
+	fmt.Println("helper ran")
+	code, _ := strconv.Atoi(args[2])
+	os.Exit(code)
+}
+
+// postDownCommand returns a post-down command running the test binary as helper process
+func postDownCommand(t *testing.T, output string, exitCode int) []string {
+	assert.NilError(t, os.Setenv("POST_DOWN_HELPER_PROCESS", "1"))
+	t.Cleanup(func() {
+		_ = os.Unsetenv("POST_DOWN_HELPER_PROCESS")
+	})
+	return []string{os.Args[0], "-test.run=^TestPostDownHelperProcess$", "--", output, strconv.Itoa(exitCode)}
+}
+
+func TestDownPostDownCommand(t *testing.T) {
+	dir := fs.NewDir(t, "postdown")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:         testProject("p", "web", "db"),
+		PostDownCommand: postDownCommand(t, dir.Join("env"), 0),
+	})
+	assert.NilError(t, err)
+	env, err := ioutil.ReadFile(dir.Join("env"))
+	assert.NilError(t, err)
+	for _, expected := range []string{"COMPOSE_PROJECT_NAME=p", "COMPOSE_REMOVED_CONTAINERS=2", "COMPOSE_REMOVED_NETWORKS=1", "COMPOSE_REMOVED_VOLUMES=0"} {
+		assert.Assert(t, strings.Contains(string(env), expected), expected)
+	}
+	assert.DeepEqual(t, w.statusOf(postDownEventName), []string{"Running", "Exited with code 0"})
+}
+
+func TestDownPostDownCommandFailureIsWarning(t *testing.T) {
+	dir := fs.NewDir(t, "postdown")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		PostDownCommand: postDownCommand(t, dir.Join("env"), 3),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.DeepEqual(t, w.statusOf(postDownEventName), []string{"Running", "Exited with code 3"})
+}
+
+func TestDownPostDownCommandFatal(t *testing.T) {
+	dir := fs.NewDir(t, "postdown")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:              testProject("p", "web"),
+		PostDownCommand:      postDownCommand(t, dir.Join("env"), 3),
+		PostDownCommandFatal: true,
+	})
+	assert.ErrorContains(t, err, "exit status 3")
+	assert.ErrorContains(t, err, "helper ran")
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownPostDownCommandSkippedOnFailure(t *testing.T) {
+	dir := fs.NewDir(t, "postdown")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device busy")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		PostDownCommand: postDownCommand(t, dir.Join("env"), 0),
+	})
+	assert.ErrorContains(t, err, "device busy")
+	_, err = os.Stat(dir.Join("env"))
+	assert.Assert(t, os.IsNotExist(err))
+}
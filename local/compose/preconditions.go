@@ -0,0 +1,59 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+)
+
+// checkDownPreconditions runs the teardown plan without removing anything, so that a failure on any step
+// is reported before the first container is gone
+func (s *composeService) checkDownPreconditions(ctx context.Context, projectName string, toRemove Containers, withNetworks bool, inspector *inspectCache) error {
+	removed := map[string]bool{}
+	for _, c := range toRemove {
+		if _, err := inspector.inspect(ctx, c.ID); err != nil {
+			return errors.Wrapf(err, "container %s can't be removed", getCanonicalContainerName(c))
+		}
+		removed[c.ID] = true
+	}
+	if !withNetworks {
+		return nil
+	}
+
+	networks, err := s.apiClient.NetworkList(ctx, moby.NetworkListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		inspect, err := s.apiClient.NetworkInspect(ctx, n.ID, moby.NetworkInspectOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "network %s can't be removed", n.Name)
+		}
+		for id, endpoint := range inspect.Containers {
+			if !removed[id] {
+				return errors.Errorf("network %s can't be removed: in use by container %s", n.Name, endpoint.Name)
+			}
+		}
+	}
+	return nil
+}
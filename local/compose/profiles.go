@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"github.com/compose-spec/compose-go/types"
+)
+
+// servicesWithContainers restricts the project to services which have containers, as services gated behind profiles
+// which weren't active were never created. Dependencies through skipped services are kept so the teardown order holds
+func servicesWithContainers(project *types.Project, containers Containers, isDownService func(services ...string) containerPredicate) *types.Project {
+	var active []string
+	for _, service := range project.Services {
+		if len(containers.filter(isDownService(service.Name))) > 0 {
+			active = append(active, service.Name)
+		}
+	}
+	if len(active) == len(project.Services) {
+		return project
+	}
+
+	restricted := restrictServices(project, active)
+	for i, service := range restricted.Services {
+		dependsOn := types.DependsOnConfig{}
+		for _, name := range activeDependencies(project, service, active, map[string]bool{}) {
+			dependsOn[name] = service.DependsOn[name]
+		}
+		service.DependsOn = dependsOn
+		// links are only used for ordering, they are folded into dependencies
+		service.Links = nil
+		restricted.Services[i] = service
+	}
+	return restricted
+}
+
+// activeDependencies returns the active services a service depends on, directly or through inactive services
+func activeDependencies(project *types.Project, service types.ServiceConfig, active []string, seen map[string]bool) []string {
+	var dependencies []string
+	for _, name := range append(service.GetDependencies(), linkedServices(service)...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if contains(active, name) {
+			dependencies = append(dependencies, name)
+			continue
+		}
+		dependency, err := project.GetService(name)
+		if err != nil {
+			continue
+		}
+		dependencies = append(dependencies, activeDependencies(project, dependency, active, seen)...)
+	}
+	return dependencies
+}
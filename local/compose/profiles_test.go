@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestServicesWithContainers(t *testing.T) {
+	gated := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", DependsOn: map[string]types.ServiceDependency{"debug": {}}},
+			{Name: "debug", Links: []string{"db:database"}},
+			{Name: "db"},
+			{Name: "admin", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		},
+	}
+	containers := Containers{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+
+	restricted := servicesWithContainers(gated, containers, isService)
+	assert.Equal(t, len(restricted.Services), 2)
+	assert.Equal(t, restricted.Services[0].Name, "web")
+	assert.Equal(t, restricted.Services[1].Name, "db")
+	web, err := restricted.GetService("web")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, web.GetDependencies(), []string{"db"})
+	// project model is left untouched
+	assert.Equal(t, len(gated.Services), 4)
+	assert.DeepEqual(t, gated.Services[0].GetDependencies(), []string{"debug"})
+}
+
+func TestServicesWithContainersAllActive(t *testing.T) {
+	active := &types.Project{
+		Name:     "p",
+		Services: []types.ServiceConfig{{Name: "web"}, {Name: "db"}},
+	}
+	containers := Containers{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	assert.Equal(t, servicesWithContainers(active, containers, isService), active)
+}
+
+func TestDownSkipsProfileGatedServices(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+
+	gated := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", DependsOn: map[string]types.ServiceDependency{"debug": {}}},
+			{Name: "debug", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+			{Name: "db"},
+			{Name: "admin", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+		},
+	}
+	w := &recordingWriter{}
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: gated, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Containers, 2)
+	assert.Assert(t, api.callIndex("ContainerRemove", "p_web_1") < api.callIndex("ContainerRemove", "p_db_1"))
+	assert.Equal(t, len(w.statusOf("Service debug")), 0)
+	assert.Equal(t, len(w.statusOf("Service admin")), 0)
+}
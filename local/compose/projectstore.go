@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/config"
+)
+
+// projectStore keeps track of known projects locally, for projects to be listed without a daemon round-trip
+type projectStore interface {
+	// Add records a project, replacing the entry of a project already in the store
+	Add(projectName string, entry projectEntry) error
+	// Remove forgets a project, removing a project which is not in the store is not an error
+	Remove(projectName string) error
+}
+
+// projectEntry is what the store records about a project, to load its model back
+type projectEntry struct {
+	WorkingDir  string   `json:"workingDir"`
+	ConfigFiles []string `json:"configFiles"`
+}
+
+// fileProjectStore stores known projects as a JSON object, by project name
+type fileProjectStore struct {
+	path string
+}
+
+// projectStoreFile is the path of the default project store in the config directory
+func projectStoreFile(configDir string) string {
+	return filepath.Join(configDir, "compose", "projects.json")
+}
+
+func (s fileProjectStore) Add(projectName string, entry projectEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal project store entry")
+	}
+	return s.update(true, func(entries map[string]json.RawMessage) bool {
+		entries[projectName] = data
+		return true
+	})
+}
+
+func (s fileProjectStore) Remove(projectName string) error {
+	return s.update(false, func(entries map[string]json.RawMessage) bool {
+		if _, ok := entries[projectName]; !ok {
+			return false
+		}
+		delete(entries, projectName)
+		return true
+	})
+}
+
+// update applies a change to the stored entries, returning whether they changed. A missing store is only created when
+// create is set. The store is locked like the history file, so that concurrent updates don't lose entries
+func (s fileProjectStore) update(create bool, change func(entries map[string]json.RawMessage) bool) error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) && !create {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return errors.Wrap(err, "unable to create project store")
+	}
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return errors.Wrap(err, "unable to lock project store")
+	}
+	defer unlock()
+
+	entries := map[string]json.RawMessage{}
+	data, err := ioutil.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err) && !create:
+		return nil
+	case os.IsNotExist(err):
+	case err != nil:
+		return errors.Wrap(err, "unable to read project store")
+	default:
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return errors.Wrap(err, "unable to unmarshal project store "+s.path)
+		}
+	}
+	if !change(entries) {
+		return nil
+	}
+	data, err = json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal project store")
+	}
+	return errors.Wrap(writeFileAtomic(s.path, data, 0644), "unable to write project store")
+}
+
+// writeFileAtomic writes a temporary file next to path then renames it, for concurrent readers never to see a truncated file
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name()) // nolint:errcheck
+	}
+	return err
+}
+
+// projectStoreFromContext returns the store projects are recorded into, nil if there is none
+func (s *composeService) projectStoreFromContext(ctx context.Context) projectStore {
+	if s.store != nil {
+		return s.store
+	}
+	configDir := config.Dir(ctx)
+	if configDir == "" {
+		return nil
+	}
+	return fileProjectStore{path: projectStoreFile(configDir)}
+}
+
+// rememberProject records a created project in the local project store, when requested by CreateOptions.RecordProject.
+// The store is a cache of the daemon state, so failing to update it is only reported as a warning
+func (s *composeService) rememberProject(ctx context.Context, project *types.Project) {
+	store := s.projectStoreFromContext(ctx)
+	if store == nil {
+		return
+	}
+	entry := projectEntry{WorkingDir: project.WorkingDir, ConfigFiles: project.ComposeFiles}
+	if err := store.Add(project.Name, entry); err != nil {
+		logrus.Warnf("failed to record project %s into the local project store: %v", project.Name, err)
+	}
+}
+
+// forgetProject removes a torn down project from the local project store, see rememberProject
+func (s *composeService) forgetProject(ctx context.Context, projectName string) {
+	store := s.projectStoreFromContext(ctx)
+	if store == nil {
+		return
+	}
+	if err := store.Remove(projectName); err != nil {
+		logrus.Warnf("failed to remove project %s from the local project store: %v", projectName, err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/config"
+)
+
+const testProjectStore = `{
+	"p": {"workingDir": "/src/p", "configFiles": ["/src/p/compose.yaml"]},
+	"q": {"workingDir": "/src/q", "configFiles": ["/src/q/compose.yaml"]}
+}`
+
+func withProjectStore(t *testing.T) *fs.Dir {
+	return fs.NewDir(t, "config", fs.WithDir("compose", fs.WithFile("projects.json", testProjectStore)))
+}
+
+func storedProjects(t *testing.T, configDir string) []string {
+	data, err := ioutil.ReadFile(projectStoreFile(configDir))
+	assert.NilError(t, err)
+	entries := map[string]json.RawMessage{}
+	assert.NilError(t, json.Unmarshal(data, &entries))
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDownRemovesProjectFromStore(t *testing.T) {
+	configDir := withProjectStore(t)
+	defer configDir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	ctx := config.WithDir(context.TODO(), configDir.Path())
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, storedProjects(t, configDir.Path()), []string{"q"})
+	_, err = os.Stat(projectStoreFile(configDir.Path()) + ".tmp")
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestDownKeepsStoreEntryOfRunningProject(t *testing.T) {
+	for name, setup := range map[string]func(api *fakeClient, options *compose.DownOptions){
+		"partial": func(api *fakeClient, options *compose.DownOptions) {
+			options.ServiceReplicas = map[string][]int{"web": {1}}
+		},
+		"failed": func(api *fakeClient, options *compose.DownOptions) {
+			api.errors["ContainerRemove p_web_2"] = fmt.Errorf("device or resource busy")
+		},
+	} {
+		configDir := withProjectStore(t)
+		defer configDir.Remove()
+		api := newFakeClient()
+		api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+		tested := composeService{apiClient: api}
+		options := compose.DownOptions{Project: testProject("p", "web")}
+		setup(api, &options)
+
+		ctx := config.WithDir(context.TODO(), configDir.Path())
+		_ = tested.Down(ctx, "p", options)
+		assert.DeepEqual(t, storedProjects(t, configDir.Path()), []string{"p", "q"})
+		data, err := ioutil.ReadFile(projectStoreFile(configDir.Path()))
+		assert.NilError(t, err)
+		assert.Equal(t, string(data), testProjectStore, name)
+	}
+}
+
+func TestDownWithoutProjectStore(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	ctx := config.WithDir(context.TODO(), configDir.Path())
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	_, err = os.Stat(projectStoreFile(configDir.Path()))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+type failingProjectStore struct {
+	removed []string
+}
+
+func (s *failingProjectStore) Add(projectName string, entry projectEntry) error {
+	return fmt.Errorf("store is locked")
+}
+
+func (s *failingProjectStore) Remove(projectName string) error {
+	s.removed = append(s.removed, projectName)
+	return fmt.Errorf("store is locked")
+}
+
+func TestDownProjectStoreFailureIsNotFatal(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	store := &failingProjectStore{}
+	tested := composeService{apiClient: api, store: store}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, store.removed, []string{"p"})
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestRememberProject(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	tested := composeService{apiClient: newFakeClient()}
+	ctx := config.WithDir(context.TODO(), configDir.Path())
+
+	project := testProject("p", "web")
+	project.WorkingDir = "/src/p"
+	project.ComposeFiles = []string{"/src/p/compose.yaml"}
+	tested.rememberProject(ctx, project)
+	tested.rememberProject(ctx, testProject("q", "web"))
+	assert.DeepEqual(t, storedProjects(t, configDir.Path()), []string{"p", "q"})
+
+	data, err := ioutil.ReadFile(projectStoreFile(configDir.Path()))
+	assert.NilError(t, err)
+	entries := map[string]projectEntry{}
+	assert.NilError(t, json.Unmarshal(data, &entries))
+	assert.DeepEqual(t, entries["p"], projectEntry{WorkingDir: "/src/p", ConfigFiles: []string{"/src/p/compose.yaml"}})
+
+	tested.forgetProject(ctx, "p")
+	assert.DeepEqual(t, storedProjects(t, configDir.Path()), []string{"q"})
+}
+
+func TestProjectStoreConcurrentUpdates(t *testing.T) {
+	configDir := fs.NewDir(t, "config")
+	defer configDir.Remove()
+	store := fileProjectStore{path: projectStoreFile(configDir.Path())}
+
+	var expected []string
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("p%d", i)
+		expected = append(expected, name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Check(t, store.Add(name, projectEntry{WorkingDir: "/src/" + name}))
+		}()
+	}
+	wg.Wait()
+	assert.DeepEqual(t, storedProjects(t, configDir.Path()), expected)
+
+	files, err := ioutil.ReadDir(filepath.Dir(store.path))
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1, "temporary and lock files must be removed")
+}
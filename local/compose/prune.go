@@ -0,0 +1,65 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/go-units"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// pruneDanglingImages removes untagged images built for the project. Only images with the project label
+// are selected, so that images shared with other projects are left untouched
+func (s *composeService) pruneDanglingImages(ctx context.Context, projectName string, options compose.DownOptions, summary *downSummary) error {
+	dangling := filters.NewArgs(
+		filters.Arg("dangling", "true"),
+		projectFilter(projectName),
+	)
+	if options.Confirm != nil {
+		images, err := s.apiClient.ImageList(ctx, moby.ImageListOptions{Filters: dangling})
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, image := range images {
+			ids = append(ids, image.ID)
+		}
+		ok, err := confirmed(options, compose.DestructiveAction{Kind: compose.ImagesRemoval, Resources: ids})
+		if !ok || err != nil {
+			return err
+		}
+	}
+
+	w := progress.ContextWriter(ctx)
+	eventName := "Dangling images"
+	w.Event(progress.RemovingEvent(eventName))
+	report, err := s.apiClient.ImagesPrune(ctx, dangling)
+	if err != nil {
+		w.Event(progress.ErrorEvent(eventName))
+		return err
+	}
+	summary.spaceReclaimed(int64(report.SpaceReclaimed))
+	w.Event(progress.NewEvent(eventName, progress.Done, fmt.Sprintf("Removed %d, reclaimed %s",
+		len(report.ImagesDeleted), units.HumanSize(float64(report.SpaceReclaimed)))))
+	return nil
+}
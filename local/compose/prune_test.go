@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownPruneDangling(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:old", Size: 1000, Labels: map[string]string{projectLabel: "p"}},
+		{ID: "sha256:web", Size: 1000, RepoTags: []string{"p_web:latest"}, Labels: map[string]string{projectLabel: "p"}},
+		{ID: "sha256:other", Size: 1000, Labels: map[string]string{projectLabel: "other"}},
+		{ID: "sha256:unlabeled", Size: 1000},
+	}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx := progress.WithContextWriter(context.TODO(), w)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), PruneDangling: true})
+	assert.NilError(t, err)
+	var ids []string
+	for _, image := range api.images {
+		ids = append(ids, image.ID)
+	}
+	assert.DeepEqual(t, ids, []string{"sha256:web", "sha256:other", "sha256:unlabeled"})
+	assert.DeepEqual(t, w.statusOf("Dangling images"), []string{"Removing", "Removed 1, reclaimed 1kB"})
+}
+
+func TestDownWithoutPruneDangling(t *testing.T) {
+	api := newFakeClient()
+	api.images = []moby.ImageSummary{{ID: "sha256:old", Labels: map[string]string{projectLabel: "p"}}}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.images), 1)
+	assert.Equal(t, len(api.callsTo("ImagesPrune")), 0)
+}
@@ -0,0 +1,304 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	volume_api "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// callLimiter is a token bucket holding a single token, so calls are evenly spread instead of sent in bursts
+type callLimiter struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newCallLimiter(callsPerSecond float64) *callLimiter {
+	return &callLimiter{interval: time.Duration(float64(time.Second) / callsPerSecond)}
+}
+
+// wait blocks until a call is allowed, or the context is done
+func (l *callLimiter) wait(ctx context.Context) error {
+	l.mtx.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mtx.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimitedClient limits the rate of the daemon calls compose makes to tear down a project, so that a shared or
+// remote daemon doesn't get a burst of concurrent calls
+type rateLimitedClient struct {
+	client.APIClient
+	limiter *callLimiter
+}
+
+func (c rateLimitedClient) ContainerList(ctx context.Context, options moby.ContainerListOptions) ([]moby.Container, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.ContainerList(ctx, options)
+}
+
+func (c rateLimitedClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return container.ContainerCreateCreatedBody{}, err
+	}
+	return c.APIClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+}
+
+func (c rateLimitedClient) CheckpointList(ctx context.Context, container string, options moby.CheckpointListOptions) ([]moby.Checkpoint, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.CheckpointList(ctx, container, options)
+}
+
+func (c rateLimitedClient) CheckpointDelete(ctx context.Context, container string, options moby.CheckpointDeleteOptions) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.CheckpointDelete(ctx, container, options)
+}
+
+func (c rateLimitedClient) ContainerInspect(ctx context.Context, id string) (moby.ContainerJSON, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.ContainerJSON{}, err
+	}
+	return c.APIClient.ContainerInspect(ctx, id)
+}
+
+func (c rateLimitedClient) ContainerLogs(ctx context.Context, id string, options moby.ContainerLogsOptions) (io.ReadCloser, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.ContainerLogs(ctx, id, options)
+}
+
+func (c rateLimitedClient) ContainerStats(ctx context.Context, id string, stream bool) (moby.ContainerStats, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.ContainerStats{}, err
+	}
+	return c.APIClient.ContainerStats(ctx, id, stream)
+}
+
+func (c rateLimitedClient) ContainerCommit(ctx context.Context, id string, options moby.ContainerCommitOptions) (moby.IDResponse, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.IDResponse{}, err
+	}
+	return c.APIClient.ContainerCommit(ctx, id, options)
+}
+
+func (c rateLimitedClient) ContainerExecCreate(ctx context.Context, id string, config moby.ExecConfig) (moby.IDResponse, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.IDResponse{}, err
+	}
+	return c.APIClient.ContainerExecCreate(ctx, id, config)
+}
+
+func (c rateLimitedClient) ContainerExecStart(ctx context.Context, execID string, config moby.ExecStartCheck) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.ContainerExecStart(ctx, execID, config)
+}
+
+func (c rateLimitedClient) ContainerExecInspect(ctx context.Context, execID string) (moby.ContainerExecInspect, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.ContainerExecInspect{}, err
+	}
+	return c.APIClient.ContainerExecInspect(ctx, execID)
+}
+
+func (c rateLimitedClient) ContainerKill(ctx context.Context, id string, signal string) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.ContainerKill(ctx, id, signal)
+}
+
+func (c rateLimitedClient) ContainerUpdate(ctx context.Context, id string, config container.UpdateConfig) (container.ContainerUpdateOKBody, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return container.ContainerUpdateOKBody{}, err
+	}
+	return c.APIClient.ContainerUpdate(ctx, id, config)
+}
+
+func (c rateLimitedClient) ContainerStop(ctx context.Context, id string, timeout *time.Duration) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.ContainerStop(ctx, id, timeout)
+}
+
+func (c rateLimitedClient) ContainerWait(ctx context.Context, id string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		errC := make(chan error, 1)
+		errC <- err
+		return nil, errC
+	}
+	return c.APIClient.ContainerWait(ctx, id, condition)
+}
+
+func (c rateLimitedClient) ContainerRemove(ctx context.Context, id string, options moby.ContainerRemoveOptions) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.ContainerRemove(ctx, id, options)
+}
+
+func (c rateLimitedClient) NetworkList(ctx context.Context, options moby.NetworkListOptions) ([]moby.NetworkResource, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.NetworkList(ctx, options)
+}
+
+func (c rateLimitedClient) NetworkInspect(ctx context.Context, id string, options moby.NetworkInspectOptions) (moby.NetworkResource, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.NetworkResource{}, err
+	}
+	return c.APIClient.NetworkInspect(ctx, id, options)
+}
+
+func (c rateLimitedClient) NetworkRemove(ctx context.Context, id string) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.NetworkRemove(ctx, id)
+}
+
+func (c rateLimitedClient) NetworkDisconnect(ctx context.Context, id string, container string, force bool) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.NetworkDisconnect(ctx, id, container, force)
+}
+
+func (c rateLimitedClient) ImageTag(ctx context.Context, source string, target string) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.ImageTag(ctx, source, target)
+}
+
+func (c rateLimitedClient) ImageRemove(ctx context.Context, id string, options moby.ImageRemoveOptions) ([]moby.ImageDeleteResponseItem, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.ImageRemove(ctx, id, options)
+}
+
+func (c rateLimitedClient) ImageInspectWithRaw(ctx context.Context, id string) (moby.ImageInspect, []byte, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.ImageInspect{}, nil, err
+	}
+	return c.APIClient.ImageInspectWithRaw(ctx, id)
+}
+
+func (c rateLimitedClient) ImageList(ctx context.Context, options moby.ImageListOptions) ([]moby.ImageSummary, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.APIClient.ImageList(ctx, options)
+}
+
+func (c rateLimitedClient) NetworksPrune(ctx context.Context, args filters.Args) (moby.NetworksPruneReport, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.NetworksPruneReport{}, err
+	}
+	return c.APIClient.NetworksPrune(ctx, args)
+}
+
+func (c rateLimitedClient) ImagesPrune(ctx context.Context, args filters.Args) (moby.ImagesPruneReport, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.ImagesPruneReport{}, err
+	}
+	return c.APIClient.ImagesPrune(ctx, args)
+}
+
+func (c rateLimitedClient) VolumeList(ctx context.Context, args filters.Args) (volume_api.VolumeListOKBody, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return volume_api.VolumeListOKBody{}, err
+	}
+	return c.APIClient.VolumeList(ctx, args)
+}
+
+func (c rateLimitedClient) VolumeInspect(ctx context.Context, id string) (moby.Volume, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.Volume{}, err
+	}
+	return c.APIClient.VolumeInspect(ctx, id)
+}
+
+func (c rateLimitedClient) VolumeCreate(ctx context.Context, options volume_api.VolumeCreateBody) (moby.Volume, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.Volume{}, err
+	}
+	return c.APIClient.VolumeCreate(ctx, options)
+}
+
+func (c rateLimitedClient) VolumeRemove(ctx context.Context, id string, force bool) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.APIClient.VolumeRemove(ctx, id, force)
+}
+
+func (c rateLimitedClient) DiskUsage(ctx context.Context) (moby.DiskUsage, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return moby.DiskUsage{}, err
+	}
+	return c.APIClient.DiskUsage(ctx)
+}
+
+func (c rateLimitedClient) Events(ctx context.Context, options moby.EventsOptions) (<-chan events.Message, <-chan error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		errC := make(chan error, 1)
+		errC <- err
+		return nil, errC
+	}
+	return c.APIClient.Events(ctx, options)
+}
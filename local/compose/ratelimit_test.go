@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestCallLimiter(t *testing.T) {
+	limiter := newCallLimiter(50)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		assert.NilError(t, limiter.wait(context.TODO()))
+	}
+	// first call is immediate, the 9 others are spread by 20ms
+	elapsed := time.Since(start)
+	assert.Assert(t, elapsed >= 180*time.Millisecond, elapsed)
+}
+
+func TestCallLimiterCanceled(t *testing.T) {
+	limiter := newCallLimiter(0.1)
+	assert.NilError(t, limiter.wait(context.TODO()))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := limiter.wait(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Assert(t, time.Since(start) < time.Second)
+}
+
+func TestDownRateLimit(t *testing.T) {
+	api := newFakeClient()
+	for i := 1; i <= 5; i++ {
+		api.containers = append(api.containers, testContainer("p", "web", i))
+	}
+	tested := composeService{apiClient: api}
+
+	const rate = 100
+	start := time.Now()
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RateLimit: rate})
+	assert.NilError(t, err)
+	elapsed := time.Since(start)
+	assert.Equal(t, len(api.containers), 0)
+
+	calls := len(api.calls)
+	assert.Assert(t, calls > 10)
+	// first call is immediate
+	minimum := time.Duration(calls-1) * time.Second / rate
+	assert.Assert(t, elapsed >= minimum, "%d calls in %s", calls, elapsed)
+}
+
+func TestDownRateLimitCanceled(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	tested := composeService{apiClient: api}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), RateLimit: 1})
+	assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// writableProbeLabel selects no network, so that probing the daemon with a prune removes nothing
+const writableProbeLabel = "com.docker.compose.writable-probe"
+
+// checkDaemonWritable probes the daemon with a mutating call removing nothing, so that a daemon rejecting all changes,
+// typically through an authorization plugin, is reported once before anything is removed, rather than as a partial
+// teardown with a failure for each resource
+func (s *composeService) checkDaemonWritable(ctx context.Context, projectName string) error {
+	_, err := s.apiClient.NetworksPrune(ctx, filters.NewArgs(filters.Arg("label", writableProbeLabel)))
+	switch {
+	case err == nil:
+		return nil
+	case errdefs.IsForbidden(err), errdefs.IsUnauthorized(err):
+		return errors.Errorf("project %s was not removed, the docker daemon is read-only: %v", projectName, err)
+	default:
+		// such as a concurrent prune, which doesn't tell whether changes are allowed
+		logrus.Debugf("unable to check the docker daemon accepts changes: %v", err)
+		return nil
+	}
+}
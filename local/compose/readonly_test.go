@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownReadOnlyDaemon(t *testing.T) {
+	api := newFakeClient()
+	api.readOnly = true
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CheckWritable: true})
+	assert.Error(t, err, "project p was not removed, the docker daemon is read-only: authorization denied by plugin readonly: NetworksPrune is not allowed")
+	// the probe is the only mutating call
+	for _, call := range mutatingCalls {
+		if call != "NetworksPrune" {
+			assert.Equal(t, len(api.callsTo(call)), 0, "unexpected %s call", call)
+		}
+	}
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownWritableDaemonProbeRemovesNothing(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	unused := moby.NetworkResource{ID: "unused", Name: "unused", Containers: map[string]moby.EndpointResource{}}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default"), unused}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CheckWritable: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("NetworksPrune"), []string{writableProbeLabel})
+	assert.Equal(t, len(api.containers), 0)
+	assert.DeepEqual(t, api.networks, []moby.NetworkResource{unused})
+}
+
+func TestDownInconclusiveWritableProbe(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["NetworksPrune "+writableProbeLabel] = errdefs.Conflict(fmt.Errorf("a prune operation is already running"))
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), CheckWritable: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+const reclaimedEventName = "Reclaimed space"
+
+// collectReclaimableSizes records in summary the size of volumes and images about to be removed, so that the space they
+// free can be reported once gone. Sizes are estimates: layers shared between images are counted for each of them
+func (s *composeService) collectReclaimableSizes(ctx context.Context, projectName string, images []string, options compose.DownOptions, summary *downSummary) {
+	if options.Volumes {
+		sizes, err := s.projectVolumeSizes(ctx, projectName)
+		if err != nil {
+			logrus.Warnf("failed to collect volumes size for project %s: %v", projectName, err)
+		}
+		summary.volumeSizes = sizes
+	}
+
+	summary.imageSizes = map[string]int64{}
+	for _, image := range images {
+		inspect, _, err := s.apiClient.ImageInspectWithRaw(ctx, image)
+		if err != nil {
+			logrus.Warnf("failed to collect size of image %s: %v", image, err)
+			continue
+		}
+		summary.imageSizes[image] = inspect.Size
+	}
+}
+
+func (s *composeService) projectVolumeSizes(ctx context.Context, projectName string) (map[string]int64, error) {
+	volumes, err := s.apiClient.VolumeList(ctx, filters.NewArgs(projectFilter(projectName)))
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes.Volumes) == 0 {
+		return nil, nil
+	}
+	return s.volumeSizes(ctx)
+}
+
+func reportReclaimedSpace(ctx context.Context, summary *downSummary) {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(reclaimedEventName, progress.Done, units.HumanSize(float64(summary.result().ReclaimedSpace))))
+}
+
+// imageDeleted tells an image was actually deleted, not only untagged
+func imageDeleted(items []moby.ImageDeleteResponseItem) bool {
+	for _, item := range items {
+		if item.Deleted != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownReportsReclaimedSpace(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.ImageID = "sha256:web"
+	api.containers = []moby.Container{web}
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024), testVolume("p", "cache", 512), testVolume("other", "data", 4096)}
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:web", Size: 1000},
+		// still tagged for another use once untagged, so no space is freed
+		{ID: "sha256:postgres", Size: 2000, RepoTags: []string{"postgres:latest", "registry.local/postgres:13"}},
+		{ID: "sha256:dangling", Size: 300, Labels: map[string]string{projectLabel: "p"}},
+	}
+	project := &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "web", Image: "nginx"},
+		{Name: "db", Image: "postgres"},
+	}}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:              project,
+		Volumes:              true,
+		RemoveImages:         compose.RemoveImagesAll,
+		PruneDangling:        true,
+		ReportReclaimedSpace: true,
+		Result:               &result,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Volumes, 2)
+	assert.DeepEqual(t, result.RemovedImages, []string{"sha256:web", "postgres"})
+	assert.Equal(t, result.ReclaimedSpace, int64(1024+512+1000+300))
+	assert.DeepEqual(t, w.statusOf(reclaimedEventName), []string{"2.836kB"})
+}
+
+func TestDownReclaimedSpaceMissingSizes(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024)}
+	api.errors["DiskUsage "] = errdefs.Unavailable(fmt.Errorf("disk usage is disabled"))
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true, ReportReclaimedSpace: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Volumes, 1)
+	assert.Equal(t, result.ReclaimedSpace, int64(0))
+}
+
+func TestDownReclaimedSpaceNotRequested(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{testVolume("p", "data", 1024)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, result.ReclaimedSpace, int64(0))
+	assert.Equal(t, len(api.callsTo("DiskUsage")), 0)
+}
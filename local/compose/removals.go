@@ -0,0 +1,181 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+var (
+	// containerRemovalTimeout is how long destroy events are waited for before falling back to polling
+	containerRemovalTimeout = 10 * time.Second
+	// containerRemovalPollInterval is the delay between inspections of a container which still exists
+	containerRemovalPollInterval = 100 * time.Millisecond
+)
+
+// containerRemovals confirms containers removal from the daemon destroy events, correlated by container ID. This saves
+// inspecting every container of large projects, polling being only a fallback when the event stream is unavailable
+type containerRemovals struct {
+	apiClient client.APIClient
+	cancel    context.CancelFunc
+
+	mtx       sync.Mutex
+	destroyed map[string]bool
+	// changed is closed and replaced each time a container is destroyed or the event stream fails
+	changed chan struct{}
+	polling bool
+}
+
+// watchContainerRemovals subscribes to the project destroy events, if removal verification is requested
+func (s *composeService) watchContainerRemovals(ctx context.Context, projectName string, options compose.DownOptions) *containerRemovals {
+	if !options.VerifyContainersRemoved {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &containerRemovals{
+		apiClient: s.apiClient,
+		cancel:    cancel,
+		destroyed: map[string]bool{},
+		changed:   make(chan struct{}),
+	}
+	messages, errs := s.apiClient.Events(ctx, moby.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", events.ContainerEventType),
+			filters.Arg("event", "destroy"),
+			projectFilter(projectName),
+		),
+	})
+	go r.collect(ctx, messages, errs)
+	return r
+}
+
+func (r *containerRemovals) collect(ctx context.Context, messages <-chan events.Message, errs <-chan error) {
+	for {
+		select {
+		case message := <-messages:
+			r.update(func() {
+				r.destroyed[message.Actor.ID] = true
+			})
+		case err := <-errs:
+			if ctx.Err() == nil {
+				logrus.Debugf("event stream unavailable, polling for containers removal: %v", err)
+				r.update(func() {
+					r.polling = true
+				})
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *containerRemovals) update(fn func()) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	fn()
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *containerRemovals) state(id string) (bool, bool, <-chan struct{}) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.destroyed[id], r.polling, r.changed
+}
+
+func (r *containerRemovals) stop() {
+	if r != nil {
+		r.cancel()
+	}
+}
+
+// confirm waits for the destroy event of each container, inspecting the ones for which none is received in time
+func (r *containerRemovals) confirm(ctx context.Context, ids []string) error {
+	timeout := time.NewTimer(containerRemovalTimeout)
+	defer timeout.Stop()
+	for _, id := range ids {
+		for {
+			destroyed, polling, changed := r.state(id)
+			if destroyed {
+				break
+			}
+			if polling {
+				if err := r.poll(ctx, id, time.Now().Add(containerRemovalTimeout)); err != nil {
+					return err
+				}
+				break
+			}
+			select {
+			case <-changed:
+			case <-timeout.C:
+				logrus.Debugf("no destroy event received for container %s, polling for its removal", id)
+				r.update(func() {
+					r.polling = true
+				})
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+func (r *containerRemovals) poll(ctx context.Context, id string, deadline time.Time) error {
+	for {
+		_, err := r.apiClient.ContainerInspect(ctx, id)
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify container %s was removed", id)
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("container %s still exists after removal", id)
+		}
+		select {
+		case <-time.After(containerRemovalPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// verifyRemovals checks removed containers, and anonymous volumes attached to them, no longer exist
+func (s *composeService) verifyRemovals(ctx context.Context, removals *containerRemovals, anonymousVolumes []string, summary *downSummary) error {
+	if removals != nil {
+		if err := removals.confirm(ctx, summary.removedContainerIDs()); err != nil {
+			return err
+		}
+	}
+	if len(anonymousVolumes) > 0 {
+		return s.verifyVolumesRemoved(ctx, anonymousVolumes, summary)
+	}
+	return nil
+}
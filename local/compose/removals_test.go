@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownVerifiesContainersRemovalFromEvents(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.State = "exited"
+	db := testContainer("p", "db", 1)
+	db.State = "exited"
+	api.containers = []moby.Container{web, db}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), VerifyContainersRemoved: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("Events")), 1)
+	// destroy events confirmed removal, no container had to be inspected
+	assert.Equal(t, len(api.callsTo("ContainerInspect")), 0)
+}
+
+func TestDownVerifiesContainersRemovalByPolling(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.State = "exited"
+	db := testContainer("p", "db", 1)
+	db.State = "exited"
+	api.containers = []moby.Container{web, db}
+	api.errors["Events "] = errdefs.Unavailable(fmt.Errorf("event stream is disabled"))
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), VerifyContainersRemoved: true})
+	assert.NilError(t, err)
+	inspected := api.callsTo("ContainerInspect")
+	sort.Strings(inspected)
+	assert.DeepEqual(t, inspected, []string{"p_db_1", "p_web_1"})
+}
+
+func TestContainerRemovalsCorrelateByID(t *testing.T) {
+	defer func(timeout time.Duration) { containerRemovalTimeout = timeout }(containerRemovalTimeout)
+	containerRemovalTimeout = 50 * time.Millisecond
+	api := newFakeClient()
+	tested := composeService{apiClient: api}
+	removals := tested.watchContainerRemovals(context.TODO(), "p", compose.DownOptions{VerifyContainersRemoved: true})
+	defer removals.stop()
+
+	labels := map[string]string{projectLabel: "p"}
+	api.publish(events.Message{Type: events.ContainerEventType, Action: "destroy", Actor: events.Actor{ID: "p_web_1", Attributes: labels}})
+	api.publish(events.Message{Type: events.ContainerEventType, Action: "destroy", Actor: events.Actor{ID: "p_db_2", Attributes: labels}})
+
+	err := removals.confirm(context.TODO(), []string{"p_web_1", "p_db_1"})
+	assert.NilError(t, err)
+	// the destroy event of another container doesn't confirm p_db_1, which is inspected once the timeout expires
+	assert.DeepEqual(t, api.callsTo("ContainerInspect"), []string{"p_db_1"})
+}
+
+func TestContainerRemovalsContainerStillExists(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		containerRemovalTimeout = timeout
+		containerRemovalPollInterval = interval
+	}(containerRemovalTimeout, containerRemovalPollInterval)
+	containerRemovalTimeout = 20 * time.Millisecond
+	containerRemovalPollInterval = 5 * time.Millisecond
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+	removals := tested.watchContainerRemovals(context.TODO(), "p", compose.DownOptions{VerifyContainersRemoved: true})
+	defer removals.stop()
+
+	err := removals.confirm(context.TODO(), []string{"p_web_1"})
+	assert.Error(t, err, "container p_web_1 still exists after removal")
+}
+
+func TestContainerRemovalsNotRequested(t *testing.T) {
+	api := newFakeClient()
+	tested := composeService{apiClient: api}
+	removals := tested.watchContainerRemovals(context.TODO(), "p", compose.DownOptions{})
+	assert.Assert(t, removals == nil)
+	removals.stop()
+	assert.Equal(t, len(api.callsTo("Events")), 0)
+}
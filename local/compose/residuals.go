@@ -0,0 +1,60 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// cleanupResiduals removes networks and volumes left over by a previous teardown, without reconstructing the project
+func (s *composeService) cleanupResiduals(ctx context.Context, projectName string, options compose.DownOptions) error {
+	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+		All:     true,
+	})
+	if err != nil {
+		return err
+	}
+	if len(containers) > 0 {
+		return errors.Errorf("project %s still has %d container(s), a full down is required", projectName, len(containers))
+	}
+
+	if options.RemovalStrategy == nil {
+		options.RemovalStrategy = parallelRemovalStrategy{}
+	}
+	summary := &downSummary{}
+	if options.Result != nil {
+		defer func() {
+			*options.Result = summary.result()
+		}()
+	}
+	// residual volumes are only removed when requested, and go through the same confirmation as a regular down
+	if options.Volumes {
+		options.Volumes, err = s.confirmVolumesRemoval(ctx, projectName, nil, true, options)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.removeNetworksAndVolumes(ctx, projectName, options, summary)
+	return err
+}
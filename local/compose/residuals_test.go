@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestCleanupResiduals(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default"), testNetwork("other", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0), testVolume("other", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{CleanupResiduals: true, Volumes: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Containers, 0)
+	assert.Equal(t, result.Networks, 1)
+	assert.Equal(t, result.Volumes, 1)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, api.networks[0].Labels[projectLabel], "other")
+	assert.Equal(t, len(api.volumes), 1)
+	assert.Equal(t, api.volumes[0].Labels[projectLabel], "other")
+	// project is not reconstructed from containers
+	assert.Equal(t, len(api.callsTo("ContainerInspect")), 0)
+}
+
+func TestCleanupResidualsWithContainers(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{CleanupResiduals: true})
+	assert.ErrorContains(t, err, "still has 1 container(s)")
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestCleanupResidualsVolumeFailure(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	api.errors["VolumeRemove p_data"] = fmt.Errorf("volume is in use")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{CleanupResiduals: true, Volumes: true})
+	assert.ErrorContains(t, err, "failed to remove volume p_data: volume is in use")
+}
+
+func TestCleanupResidualsVolumesNotConfirmed(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	var actions []compose.DestructiveAction
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		CleanupResiduals: true,
+		Volumes:          true,
+		Confirm: func(action compose.DestructiveAction) (bool, error) {
+			actions = append(actions, action)
+			return false, nil
+		},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, actions, []compose.DestructiveAction{{Kind: compose.VolumesRemoval, Resources: []string{"p_data"}}})
+	assert.Equal(t, len(api.volumes), 1)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestCleanupResidualsKeepsVolumesByDefault(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{CleanupResiduals: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.volumes), 1)
+	assert.Equal(t, len(api.callsTo("VolumeRemove")), 0)
+	assert.Equal(t, len(api.networks), 0)
+	assert.Equal(t, result.Volumes, 0)
+}
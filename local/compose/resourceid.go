@@ -0,0 +1,47 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// resourceIDWriter sets the daemon identifier of a resource on the events about it, for tooling correlating progress
+// with daemon resources
+type resourceIDWriter struct {
+	progress.Writer
+	eventID    string
+	resourceID string
+}
+
+func (w resourceIDWriter) Event(e progress.Event) {
+	if e.ID == w.eventID && e.ResourceID == "" {
+		e.ResourceID = w.resourceID
+	}
+	w.Writer.Event(e)
+}
+
+// withResourceID returns a context which writer sets resourceID on events identified by eventID
+func withResourceID(ctx context.Context, eventID string, resourceID string) context.Context {
+	return progress.WithContextWriter(ctx, resourceIDWriter{
+		Writer:     progress.ContextWriter(ctx),
+		eventID:    eventID,
+		resourceID: resourceID,
+	})
+}
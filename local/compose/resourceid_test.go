@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownEventsCarryResourceIDs(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.ID = "3f9c0d2b7a61"
+	api.containers = []moby.Container{web}
+	network := testNetwork("p", "default")
+	network.ID = "8e1d4c7f5b20"
+	api.networks = []moby.NetworkResource{network}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true})
+	assert.NilError(t, err)
+	// from stopping to removal
+	assert.DeepEqual(t, distinct(w.resourceIDsOf("Container p_web_1")), []string{"3f9c0d2b7a61"})
+	assert.DeepEqual(t, w.resourceIDsOf(`Network "p_default"`), []string{"8e1d4c7f5b20", "8e1d4c7f5b20"})
+	assert.DeepEqual(t, w.resourceIDsOf(`Volume "p_data"`), []string{"p_data", "p_data"})
+	// roll-ups are not about a single resource
+	assert.DeepEqual(t, w.resourceIDsOf("Service web"), []string{""})
+}
+
+func TestResourceIDWriterKeepsOtherEvents(t *testing.T) {
+	w := &recordingWriter{}
+	tested := resourceIDWriter{Writer: w, eventID: "Container p_web_1", resourceID: "3f9c0d2b7a61"}
+
+	tested.Event(progress.RemovingEvent("Container p_web_1"))
+	tested.Event(progress.RemovingEvent("Container p_db_1"))
+	assert.DeepEqual(t, w.resourceIDsOf("Container p_web_1"), []string{"3f9c0d2b7a61"})
+	assert.DeepEqual(t, w.resourceIDsOf("Container p_db_1"), []string{""})
+}
+
+func distinct(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if !contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+)
+
+// disableRestartPolicy resets the restart policy of a running container, so the engine doesn't restart it
+// while it is being torn down. This applies to `always`, `on-failure` and `unless-stopped` alike: user explicitly
+// requested teardown
+func (s *composeService) disableRestartPolicy(ctx context.Context, c moby.Container, inspector *inspectCache) error {
+	if c.State != "running" && c.State != "restarting" {
+		return nil
+	}
+	inspect, err := inspector.inspect(ctx, c.ID)
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if inspect.HostConfig == nil {
+		return nil
+	}
+	switch inspect.HostConfig.RestartPolicy.Name {
+	case "", "no":
+		return nil
+	}
+	_, err = s.apiClient.ContainerUpdate(ctx, c.ID, container.UpdateConfig{
+		RestartPolicy: container.RestartPolicy{Name: "no"},
+	})
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
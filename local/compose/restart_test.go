@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownUnlessStoppedContainer(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.restarts["p_web_1"] = "unless-stopped"
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	updated := api.callIndex("ContainerUpdate", "p_web_1")
+	assert.Assert(t, updated >= 0 && updated < api.callIndex("ContainerStop", "p_web_1"), "restart policy must be disabled before stop")
+	assert.Equal(t, api.restarts["p_web_1"], "no")
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownSkipsRestartPolicyOfStoppedContainers(t *testing.T) {
+	api := newFakeClient()
+	exited := testContainer("p", "web", 2)
+	exited.State = "exited"
+	api.containers = []moby.Container{testContainer("p", "web", 1), exited}
+	api.restarts["p_web_2"] = "always"
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerUpdate")), 0)
+	assert.Equal(t, len(api.containers), 0)
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// autoRetryPasses bounds the number of times the teardown runs again while project resources remain
+const autoRetryPasses = 3
+
+// downWithRetries runs the teardown then, with AutoRetryWhole, runs it again while the post-down check still finds
+// project resources, so stragglers like networks which needed more time get removed
+func (s *composeService) downWithRetries(ctx context.Context, projectName string, options compose.DownOptions) error {
+	if !options.AutoRetryWhole || keepsResources(options) {
+		return s.down(ctx, projectName, options)
+	}
+	// later passes must not rebuild the project from the containers the first pass left
+	if options.Project == nil && !options.CleanupResiduals {
+		project, err := s.downProject(ctx, projectName, options)
+		if err != nil {
+			return err
+		}
+		options.Project = project
+	}
+	w := progress.ContextWriter(ctx)
+	total := options.Result
+	for pass := 0; ; pass++ {
+		result := compose.DownResult{}
+		options.Result = &result
+		err := s.down(ctx, projectName, options)
+		options = removalOnly(options)
+		if total != nil {
+			addDownResult(total, result)
+		}
+		remaining, checkErr := s.remainingResources(ctx, projectName, options)
+		if checkErr != nil {
+			if err != nil {
+				return err
+			}
+			return checkErr
+		}
+		if remaining == 0 {
+			if pass > 0 {
+				w.Event(progress.NewEvent("Retry", progress.Done, "All resources removed"))
+			}
+			return err
+		}
+		if pass == autoRetryPasses {
+			msg := fmt.Sprintf("%d resource(s) remain after %d retries", remaining, autoRetryPasses)
+			w.Event(progress.ErrorMessageEvent("Retry", msg))
+			if err != nil {
+				return err
+			}
+			return errors.Errorf("project %s: %s", projectName, msg)
+		}
+		w.Event(progress.NewEvent("Retry", progress.Working, fmt.Sprintf("%d resource(s) remain, pass %d/%d", remaining, pass+1, autoRetryPasses)))
+	}
+}
+
+// removalOnly disables the steps run before anything is removed, for a retry pass not to overwrite what they recorded
+// of the project state before the teardown
+func removalOnly(options compose.DownOptions) compose.DownOptions {
+	options.CheckWritable = false
+	options.ValidateLabels = false
+	options.VerifyDependencyGraph = false
+	options.DiagnosticsTo = ""
+	options.DryRunThenExecute = false
+	options.UndoScript = ""
+	options.DiffHistory = false
+	return options
+}
+
+// keepsResources tells whether the teardown may keep project resources on purpose, either because it is partial or
+// because resources can be declined or preserved. Retrying would then run useless passes, and prompt again
+func keepsResources(options compose.DownOptions) bool {
+	return isPartialDown(options) || options.Confirm != nil || options.ServiceTeardown != nil || options.PreserveSharedDefault ||
+		len(options.VolumeDrivers) > 0
+}
+
+// isPartialDown tells whether the options only select some of the project containers for removal
+func isPartialDown(options compose.DownOptions) bool {
+	return options.ServiceGroupLabel != "" || len(options.ServiceReplicas) > 0 || options.OlderThan > 0 ||
+		options.DesiredStateFile != "" || len(options.KeepStates) > 0
+}
+
+// remainingResources counts the project resources the teardown was expected to remove
+func (s *composeService) remainingResources(ctx context.Context, projectName string, options compose.DownOptions) (int, error) {
+	estimate, err := s.EstimateDown(ctx, projectName, options)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.Containers + estimate.Networks + estimate.Volumes, nil
+}
+
+// addDownResult accumulates the result of a teardown pass
+func addDownResult(total *compose.DownResult, pass compose.DownResult) {
+	total.Containers += pass.Containers
+	total.Networks += pass.Networks
+	total.Volumes += pass.Volumes
+	total.StaleFirewallRules = append(total.StaleFirewallRules, pass.StaleFirewallRules...)
+	total.StuckSubnets = append(total.StuckSubnets, pass.StuckSubnets...)
+	total.PersistingVolumes = append(total.PersistingVolumes, pass.PersistingVolumes...)
+	total.HeldPorts = append(total.HeldPorts, pass.HeldPorts...)
+	total.RemovedImages = append(total.RemovedImages, pass.RemovedImages...)
+	total.ReclaimedSpace += pass.ReclaimedSpace
+	for container, image := range pass.CommittedImages {
+		if total.CommittedImages == nil {
+			total.CommittedImages = map[string]string{}
+		}
+		total.CommittedImages[container] = image
+	}
+	for image, archive := range pass.ArchivedImages {
+		if total.ArchivedImages == nil {
+			total.ArchivedImages = map[string]string{}
+		}
+		total.ArchivedImages[image] = archive
+	}
+	if total.ServiceImages == nil {
+		total.ServiceImages = pass.ServiceImages
+	}
+	for container, stats := range pass.Stats {
+		if total.Stats == nil {
+			total.Stats = map[string]compose.ContainerStats{}
+		}
+		total.Stats[container] = stats
+	}
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestAutoRetryWholeRemovesStragglers(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	api.transient["NetworkRemove p_default"] = 1
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{AutoRetryWhole: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default", "p_default"})
+	assert.Equal(t, result.Containers, 1)
+	assert.Equal(t, result.Networks, 1)
+	assert.DeepEqual(t, w.statusOf("Retry"), []string{"1 resource(s) remain, pass 1/3", "All resources removed"})
+}
+
+func TestAutoRetryWholeGivesUp(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{AutoRetryWhole: true})
+	assert.ErrorContains(t, err, "network is still in use")
+	assert.Equal(t, len(api.callsTo("NetworkRemove")), autoRetryPasses+1)
+	statuses := w.statusOf("Retry")
+	assert.Equal(t, statuses[len(statuses)-1], "1 resource(s) remain after 3 retries")
+}
+
+func TestDownWithoutAutoRetry(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	api.transient["NetworkRemove p_default"] = 1
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{})
+	assert.ErrorContains(t, err, "network is still in use")
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, len(api.callsTo("NetworkRemove")), 1)
+}
+
+func TestAutoRetryWholeSkippedWhenVolumesDeclined(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	prompts := 0
+	confirm := func(action compose.DestructiveAction) (bool, error) {
+		prompts++
+		return false, nil
+	}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{AutoRetryWhole: true, Volumes: true, Confirm: confirm})
+	assert.NilError(t, err)
+	assert.Equal(t, prompts, 1)
+	assert.Equal(t, len(api.volumes), 1)
+}
+
+func TestAutoRetryWholeSkippedWhenPreservingSharedDefault(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{AutoRetryWhole: true, PreserveSharedDefault: true})
+	assert.ErrorContains(t, err, "network is still in use")
+	assert.Equal(t, len(api.callsTo("NetworkRemove")), 1)
+}
+
+func TestAutoRetryWholeRunsPreTeardownStepsOnce(t *testing.T) {
+	dir := fs.NewDir(t, "undo")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	api.transient["NetworkRemove p_default"] = 1
+	tested := composeService{apiClient: api}
+
+	resolve := func(name string, labels map[string]string) string {
+		return labels[serviceLabel]
+	}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{AutoRetryWhole: true, ServiceResolver: resolve, UndoScript: dir.Join("undo.sh")})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default", "p_default"})
+	// the second pass would otherwise rewrite the script from a project without containers
+	script, err := ioutil.ReadFile(dir.Join("undo.sh"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(script), "# service web"))
+}
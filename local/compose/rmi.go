@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// resolveServiceImages selects the images to remove for services. Images are resolved to the exact image ID
+// service containers run, as the configured reference may have moved since, then fall back to the configured
+// reference for services without containers
+func (s *composeService) resolveServiceImages(ctx context.Context, project *types.Project, containers Containers, mode string,
+	isDownService func(services ...string) containerPredicate, inspector *inspectCache) ([]string, error) {
+	var images []string
+	add := func(image string) {
+		if image != "" && !contains(images, image) {
+			images = append(images, image)
+		}
+	}
+	for _, service := range project.Services {
+		if mode == compose.RemoveImagesLocal && service.Image != "" {
+			continue
+		}
+		serviceContainers := containers.filter(isDownService(service.Name))
+		if len(serviceContainers) == 0 {
+			add(getImageName(service, project.Name))
+			continue
+		}
+		for _, c := range serviceContainers {
+			inspect, err := inspector.inspect(ctx, c.ID)
+			if err != nil {
+				return nil, err
+			}
+			add(inspect.Image)
+		}
+	}
+	return images, nil
+}
+
+// removeImages removes images once containers using them are gone. Missing images are ignored, and images still
+// in use by other containers are kept with a warning
+func (s *composeService) removeImages(ctx context.Context, images []string, options compose.DownOptions, summary *downSummary) error {
+	ok, err := confirmed(options, compose.DestructiveAction{Kind: compose.ImagesRemoval, Resources: images})
+	if !ok || err != nil {
+		return err
+	}
+	w := progress.ContextWriter(ctx)
+	for _, image := range images {
+		eventName := fmt.Sprintf("Image %s", image)
+		w.Event(progress.RemovingEvent(eventName))
+		deleted, err := s.apiClient.ImageRemove(ctx, image, moby.ImageRemoveOptions{PruneChildren: true})
+		switch {
+		case err == nil:
+			w.Event(progress.RemovedEvent(eventName))
+			summary.imageRemoved(image)
+			if imageDeleted(deleted) {
+				summary.imageDeleted(image)
+			}
+		case errdefs.IsNotFound(err):
+			w.Event(progress.NewEvent(eventName, progress.Done, "Not found"))
+		case errdefs.IsConflict(err):
+			logrus.Warnf("image %s is still in use and was not removed: %v", image, err)
+			w.Event(progress.NewEvent(eventName, progress.Done, "In use"))
+		case errdefs.IsForbidden(err):
+			logrus.Warnf("image %s was not removed: %v", image, err)
+			w.Event(progress.NewEvent(eventName, progress.Done, "Kept, out of scope"))
+		default:
+			w.Event(progress.ErrorEvent(eventName))
+			return err
+		}
+	}
+	return nil
+}
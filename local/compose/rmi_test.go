@@ -0,0 +1,94 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownRemoveImagesFromContainers(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.ImageID = "sha256:old"
+	api.containers = []moby.Container{web}
+	// nginx:latest moved since the container was created
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:old"},
+		{ID: "sha256:new", RepoTags: []string{"nginx:latest"}},
+		{ID: "sha256:postgres", RepoTags: []string{"postgres:latest"}},
+	}
+	project := &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "web", Image: "nginx:latest"},
+		{Name: "db", Image: "postgres"},
+	}}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, RemoveImages: compose.RemoveImagesAll, Result: &result})
+	assert.NilError(t, err)
+	// container based resolution for web, config based fallback for db without containers
+	assert.DeepEqual(t, result.RemovedImages, []string{"sha256:old", "postgres"})
+	assert.DeepEqual(t, api.images, []moby.ImageSummary{{ID: "sha256:new", RepoTags: []string{"nginx:latest"}}})
+}
+
+func TestDownRemoveLocalImages(t *testing.T) {
+	api := newFakeClient()
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:web", RepoTags: []string{"p_web:latest"}},
+		{ID: "sha256:postgres", RepoTags: []string{"postgres:latest"}},
+	}
+	project := &types.Project{Name: "p", Services: []types.ServiceConfig{
+		{Name: "web", Build: &types.BuildConfig{Context: "."}},
+		{Name: "db", Image: "postgres"},
+	}}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, RemoveImages: compose.RemoveImagesLocal})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ImageRemove"), []string{"p_web"})
+	assert.DeepEqual(t, api.images, []moby.ImageSummary{{ID: "sha256:postgres", RepoTags: []string{"postgres:latest"}}})
+}
+
+func TestDownRemoveImagesInUse(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	web.ImageID = "sha256:nginx"
+	other := testContainer("other", "web", 1)
+	other.ImageID = "sha256:nginx"
+	api.containers = []moby.Container{web, other}
+	api.images = []moby.ImageSummary{{ID: "sha256:nginx", RepoTags: []string{"nginx:latest"}}}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveImages: compose.RemoveImagesAll, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.images), 1)
+	assert.Equal(t, len(result.RemovedImages), 0)
+}
+
+func TestDownRemoveImagesInvalid(t *testing.T) {
+	tested := composeService{apiClient: newFakeClient()}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveImages: "some"})
+	assert.ErrorContains(t, err, `invalid images removal "some"`)
+}
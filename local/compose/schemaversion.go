@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"strconv"
+
+	"github.com/compose-spec/compose-go/types"
+)
+
+// newerSchemaVersion returns the schema version label of a container created by a build using a newer compose model
+// format, or an unknown one, which config files could be misinterpreted with
+func newerSchemaVersion(containers Containers) (string, bool) {
+	for _, c := range containers {
+		label, ok := c.Labels[schemaVersionLabel]
+		if !ok {
+			continue
+		}
+		version, err := strconv.Atoi(label)
+		if err != nil || version > schemaVersion {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// projectFromServiceLabels reconstructs a project with only the services of containers, as compose-go can't be relied
+// on to load the config files
+func projectFromServiceLabels(projectName string, containers Containers) *types.Project {
+	project := &types.Project{Name: projectName}
+	var services []string
+	for _, c := range containers {
+		service := c.Labels[serviceLabel]
+		if service == "" || contains(services, service) {
+			continue
+		}
+		services = append(services, service)
+		project.Services = append(project.Services, types.ServiceConfig{Name: service})
+	}
+	return project
+}
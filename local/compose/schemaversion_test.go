@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func skewedContainer(service string, number int, version string) moby.Container {
+	c := testContainer("p", service, number)
+	c.Labels[schemaVersionLabel] = version
+	// config files a newer compose-go would be needed to load
+	c.Labels[configFilesLabel] = "/srv/p/compose.yaml"
+	c.Labels[workingDirLabel] = "/srv/p"
+	return c
+}
+
+func TestDownFallsBackToLabelsOnSchemaVersionSkew(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{skewedContainer("web", 1, "2"), skewedContainer("web", 2, "2"), skewedContainer("db", 1, "2")}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, result.ServiceImages, map[string]string{"web": "", "db": ""})
+}
+
+func TestNewerSchemaVersion(t *testing.T) {
+	current := testContainer("p", "web", 1)
+	current.Labels[schemaVersionLabel] = "1"
+	legacy := testContainer("p", "web", 2)
+
+	_, newer := newerSchemaVersion(Containers{current, legacy})
+	assert.Assert(t, !newer)
+	version, newer := newerSchemaVersion(Containers{current, skewedContainer("db", 1, "2")})
+	assert.Assert(t, newer)
+	assert.Equal(t, version, "2")
+	version, newer = newerSchemaVersion(Containers{skewedContainer("db", 1, "next")})
+	assert.Assert(t, newer)
+	assert.Equal(t, version, "next")
+}
+
+func TestProjectFromServiceLabels(t *testing.T) {
+	orphan := testContainer("p", "", 1)
+	project := projectFromServiceLabels("p", Containers{testContainer("p", "web", 1), testContainer("p", "web", 2), orphan, testContainer("p", "db", 1)})
+	assert.Equal(t, project.Name, "p")
+	assert.DeepEqual(t, project.ServiceNames(), []string{"web", "db"})
+}
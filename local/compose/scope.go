@@ -0,0 +1,176 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	volume_api "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// scopedClient enforces a tenant scope at the API client level: the scope labels are merged into every listing, prune
+// and events filter, and set on every created resource. As all compose operations select resources by listing,
+// resources outside the scope can never be seen nor removed. Calls addressing a resource by name or reference are
+// checked against the labels of the resource, and refused when it doesn't carry the scope labels
+type scopedClient struct {
+	client.APIClient
+	scope map[string]string
+}
+
+func (c scopedClient) withScope(args filters.Args) filters.Args {
+	scoped := args.Clone()
+	for k, v := range c.scope {
+		scoped.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	return scoped
+}
+
+func (c scopedClient) scopeLabels(labels map[string]string) map[string]string {
+	scoped := map[string]string{}
+	for k, v := range labels {
+		scoped[k] = v
+	}
+	for k, v := range c.scope {
+		scoped[k] = v
+	}
+	return scoped
+}
+
+func (c scopedClient) ContainerList(ctx context.Context, options moby.ContainerListOptions) ([]moby.Container, error) {
+	options.Filters = c.withScope(options.Filters)
+	return c.APIClient.ContainerList(ctx, options)
+}
+
+func (c scopedClient) NetworkList(ctx context.Context, options moby.NetworkListOptions) ([]moby.NetworkResource, error) {
+	options.Filters = c.withScope(options.Filters)
+	return c.APIClient.NetworkList(ctx, options)
+}
+
+func (c scopedClient) VolumeList(ctx context.Context, filter filters.Args) (volume_api.VolumeListOKBody, error) {
+	return c.APIClient.VolumeList(ctx, c.withScope(filter))
+}
+
+func (c scopedClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
+	networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	scoped := *config
+	scoped.Labels = c.scopeLabels(config.Labels)
+	return c.APIClient.ContainerCreate(ctx, &scoped, hostConfig, networkingConfig, platform, containerName)
+}
+
+func (c scopedClient) NetworkCreate(ctx context.Context, name string, options moby.NetworkCreate) (moby.NetworkCreateResponse, error) {
+	options.Labels = c.scopeLabels(options.Labels)
+	return c.APIClient.NetworkCreate(ctx, name, options)
+}
+
+func (c scopedClient) VolumeCreate(ctx context.Context, options volume_api.VolumeCreateBody) (moby.Volume, error) {
+	options.Labels = c.scopeLabels(options.Labels)
+	return c.APIClient.VolumeCreate(ctx, options)
+}
+
+func (c scopedClient) ImageList(ctx context.Context, options moby.ImageListOptions) ([]moby.ImageSummary, error) {
+	options.Filters = c.withScope(options.Filters)
+	return c.APIClient.ImageList(ctx, options)
+}
+
+func (c scopedClient) ImagesPrune(ctx context.Context, pruneFilter filters.Args) (moby.ImagesPruneReport, error) {
+	return c.APIClient.ImagesPrune(ctx, c.withScope(pruneFilter))
+}
+
+func (c scopedClient) ImageRemove(ctx context.Context, imageID string, options moby.ImageRemoveOptions) ([]moby.ImageDeleteResponseItem, error) {
+	if err := c.checkImage(ctx, imageID); err != nil {
+		return nil, err
+	}
+	return c.APIClient.ImageRemove(ctx, imageID, options)
+}
+
+func (c scopedClient) ImageTag(ctx context.Context, source, target string) error {
+	if err := c.checkImage(ctx, source); err != nil {
+		return err
+	}
+	return c.APIClient.ImageTag(ctx, source, target)
+}
+
+func (c scopedClient) checkImage(ctx context.Context, imageID string) error {
+	image, _, err := c.APIClient.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return err
+	}
+	var labels map[string]string
+	if image.Config != nil {
+		labels = image.Config.Labels
+	}
+	if !c.inScope(labels) {
+		return errdefs.Forbidden(fmt.Errorf("image %s is out of scope", imageID))
+	}
+	return nil
+}
+
+func (c scopedClient) VolumeInspect(ctx context.Context, volumeID string) (moby.Volume, error) {
+	volume, err := c.APIClient.VolumeInspect(ctx, volumeID)
+	if err != nil {
+		return moby.Volume{}, err
+	}
+	if !c.inScope(volume.Labels) {
+		return moby.Volume{}, errdefs.Forbidden(fmt.Errorf("volume %s is out of scope", volumeID))
+	}
+	return volume, nil
+}
+
+func (c scopedClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	if _, err := c.VolumeInspect(ctx, volumeID); err != nil {
+		return err
+	}
+	return c.APIClient.VolumeRemove(ctx, volumeID, force)
+}
+
+func (c scopedClient) NetworkInspect(ctx context.Context, networkID string, options moby.NetworkInspectOptions) (moby.NetworkResource, error) {
+	resource, err := c.APIClient.NetworkInspect(ctx, networkID, options)
+	if err != nil {
+		return moby.NetworkResource{}, err
+	}
+	if !c.inScope(resource.Labels) {
+		return moby.NetworkResource{}, errdefs.Forbidden(fmt.Errorf("network %s is out of scope", networkID))
+	}
+	return resource, nil
+}
+
+func (c scopedClient) NetworksPrune(ctx context.Context, pruneFilter filters.Args) (moby.NetworksPruneReport, error) {
+	return c.APIClient.NetworksPrune(ctx, c.withScope(pruneFilter))
+}
+
+func (c scopedClient) Events(ctx context.Context, options moby.EventsOptions) (<-chan events.Message, <-chan error) {
+	options.Filters = c.withScope(options.Filters)
+	return c.APIClient.Events(ctx, options)
+}
+
+func (c scopedClient) inScope(labels map[string]string) bool {
+	for k, v := range c.scope {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
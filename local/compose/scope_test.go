@@ -0,0 +1,194 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func inScope(tenant string) func(labels map[string]string) {
+	return func(labels map[string]string) {
+		labels["tenant"] = tenant
+	}
+}
+
+func TestScopedDown(t *testing.T) {
+	api := newFakeClient()
+	mine, theirs := testContainer("p", "web", 1), testContainer("p", "web", 2)
+	inScope("a")(mine.Labels)
+	inScope("b")(theirs.Labels)
+	myNetwork, theirNetwork := testNetwork("p", "default"), testNetwork("p", "back")
+	inScope("a")(myNetwork.Labels)
+	inScope("b")(theirNetwork.Labels)
+	myVolume, theirVolume := testVolume("p", "data", 0), testVolume("p", "db", 0)
+	inScope("a")(myVolume.Labels)
+	inScope("b")(theirVolume.Labels)
+	api.containers = []moby.Container{mine, theirs}
+	api.networks = []moby.NetworkResource{myNetwork, theirNetwork}
+	api.volumes = []moby.Volume{myVolume, theirVolume}
+	tested := NewScopedComposeService(api, map[string]string{"tenant": "a"})
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveOrphans: true, Volumes: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.containers, []moby.Container{theirs})
+	assert.DeepEqual(t, api.networks, []moby.NetworkResource{theirNetwork})
+	assert.DeepEqual(t, api.volumes, []moby.Volume{theirVolume})
+	assert.DeepEqual(t, api.callsTo("ContainerStop"), []string{"p_web_1"})
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_default"})
+	assert.DeepEqual(t, api.callsTo("VolumeRemove"), []string{"p_data"})
+}
+
+func TestScopedClientDoesNotAlterCallerFilters(t *testing.T) {
+	api := newFakeClient()
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+	args := filters.NewArgs(projectFilter("p"))
+
+	_, err := scoped.ContainerList(context.TODO(), moby.ContainerListOptions{Filters: args})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, args.Get("label"), []string{projectLabel + "=p"})
+}
+
+func TestScopedClientLabelsCreatedResources(t *testing.T) {
+	api := newFakeClient()
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+
+	labels := map[string]string{projectLabel: "p"}
+	v, err := scoped.VolumeCreate(context.TODO(), volume.VolumeCreateBody{Name: "p_data", Labels: labels})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, v.Labels, map[string]string{projectLabel: "p", "tenant": "a"})
+	assert.DeepEqual(t, labels, map[string]string{projectLabel: "p"})
+}
+
+func TestScopedClientImages(t *testing.T) {
+	api := newFakeClient()
+	api.images = []moby.ImageSummary{
+		{ID: "sha256:mine", Labels: map[string]string{"tenant": "a"}},
+		{ID: "sha256:theirs", Labels: map[string]string{"tenant": "b"}},
+		{ID: "sha256:shared", RepoTags: []string{"redis:latest"}},
+	}
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+
+	images, err := scoped.ImageList(context.TODO(), moby.ImageListOptions{Filters: filters.NewArgs(filters.Arg("dangling", "true"))})
+	assert.NilError(t, err)
+	assert.Equal(t, len(images), 1)
+	assert.Equal(t, images[0].ID, "sha256:mine")
+
+	_, err = scoped.ImageRemove(context.TODO(), "redis", moby.ImageRemoveOptions{})
+	assert.ErrorContains(t, err, "image redis is out of scope")
+	assert.Equal(t, len(api.callsTo("ImageRemove")), 0)
+
+	report, err := scoped.ImagesPrune(context.TODO(), filters.NewArgs(filters.Arg("dangling", "true")))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report.ImagesDeleted, []moby.ImageDeleteResponseItem{{Deleted: "sha256:mine"}})
+	assert.Equal(t, len(api.images), 2)
+
+	_, err = scoped.ImageRemove(context.TODO(), "sha256:theirs", moby.ImageRemoveOptions{})
+	assert.ErrorContains(t, err, "out of scope")
+	assert.Equal(t, len(api.images), 2)
+}
+
+func TestScopedDownKeepsImagesOutOfScope(t *testing.T) {
+	api := newFakeClient()
+	web := testContainer("p", "web", 1)
+	inScope("a")(web.Labels)
+	web.ImageID = "sha256:shared"
+	api.containers = []moby.Container{web}
+	api.images = []moby.ImageSummary{{ID: "sha256:shared", RepoTags: []string{"redis:latest"}}}
+	tested := NewScopedComposeService(api, map[string]string{"tenant": "a"})
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), RemoveImages: "all"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.images), 1)
+	assert.Equal(t, len(api.callsTo("ImageRemove")), 0)
+}
+
+func TestScopedClientNetworksPrune(t *testing.T) {
+	api := newFakeClient()
+	mine, theirs := testNetwork("p", "default"), testNetwork("p", "back")
+	inScope("a")(mine.Labels)
+	inScope("b")(theirs.Labels)
+	api.networks = []moby.NetworkResource{mine, theirs}
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+
+	report, err := scoped.NetworksPrune(context.TODO(), filters.NewArgs(projectFilter("p")))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report.NetworksDeleted, []string{"p_default"})
+	assert.DeepEqual(t, api.networks, []moby.NetworkResource{theirs})
+}
+
+func TestScopedClientEvents(t *testing.T) {
+	api := newFakeClient()
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+	args := filters.NewArgs(projectFilter("p"))
+
+	scoped.Events(context.TODO(), moby.EventsOptions{Filters: args})
+	assert.Equal(t, len(api.subscribers), 1)
+	assert.DeepEqual(t, api.subscribers[0].filters.Get("label"), []string{projectLabel + "=p", "tenant=a"})
+	// the caller filters are left untouched
+	assert.DeepEqual(t, args.Get("label"), []string{projectLabel + "=p"})
+}
+
+func TestScopedClientChecksResourcesAddressedByName(t *testing.T) {
+	api := newFakeClient()
+	myVolume, theirVolume := testVolume("p", "data", 0), testVolume("p", "db", 0)
+	inScope("a")(myVolume.Labels)
+	inScope("b")(theirVolume.Labels)
+	api.volumes = []moby.Volume{myVolume, theirVolume}
+	theirNetwork := testNetwork("p", "back")
+	inScope("b")(theirNetwork.Labels)
+	api.networks = []moby.NetworkResource{theirNetwork}
+	api.images = []moby.ImageSummary{{ID: "sha256:theirs", RepoTags: []string{"web:latest"}, Labels: map[string]string{"tenant": "b"}}}
+	scoped := scopedClient{APIClient: api, scope: map[string]string{"tenant": "a"}}
+
+	err := scoped.VolumeRemove(context.TODO(), "p_db", true)
+	assert.ErrorContains(t, err, "volume p_db is out of scope")
+	_, err = scoped.VolumeInspect(context.TODO(), "p_db")
+	assert.ErrorContains(t, err, "volume p_db is out of scope")
+	_, err = scoped.NetworkInspect(context.TODO(), "p_back", moby.NetworkInspectOptions{})
+	assert.ErrorContains(t, err, "network p_back is out of scope")
+	err = scoped.ImageTag(context.TODO(), "web", "archive/web")
+	assert.ErrorContains(t, err, "image web is out of scope")
+	assert.Equal(t, len(api.callsTo("VolumeRemove")), 0)
+	assert.Equal(t, len(api.callsTo("ImageTag")), 0)
+
+	err = scoped.VolumeRemove(context.TODO(), "p_data", true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.volumes, []moby.Volume{theirVolume})
+}
+
+func TestScopedDownKeepsTombstoneOutOfScope(t *testing.T) {
+	api := newFakeClient()
+	theirs := moby.Volume{
+		Name:   tombstoneName("p"),
+		Labels: map[string]string{tombstoneLabel: "p", tombstoneTimeLabel: "2020-01-01T00:00:00Z", "tenant": "b"},
+	}
+	api.volumes = []moby.Volume{theirs}
+	tested := NewScopedComposeService(api, map[string]string{"tenant": "a"})
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Tombstone: true})
+	assert.ErrorContains(t, err, "out of scope")
+	assert.DeepEqual(t, api.volumes, []moby.Volume{theirs})
+	assert.Equal(t, len(api.callsTo("VolumeRemove")), 0)
+}
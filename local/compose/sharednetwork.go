@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// isSharedDefaultNetwork tells if the project default network is shared with sibling stacks, either declared as
+// external or still having endpoints once project containers got removed. A shared network is reported as kept
+func (s *composeService) isSharedDefaultNetwork(ctx context.Context, project *types.Project, networkID string) (bool, error) {
+	n, err := s.apiClient.NetworkInspect(ctx, networkID, moby.NetworkInspectOptions{})
+	if err != nil {
+		return false, err
+	}
+	reason := ""
+	if project != nil && project.Networks["default"].External.External {
+		reason = "external"
+	} else if len(n.Containers) > 0 {
+		reason = fmt.Sprintf("used by %d container(s) of other projects", len(n.Containers))
+	}
+	if reason == "" {
+		return false, nil
+	}
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.NewEvent(fmt.Sprintf("Network %q", n.Name), progress.Done, "Kept, "+reason))
+	return true, nil
+}
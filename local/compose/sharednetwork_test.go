@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownPreservesSharedDefaultNetwork(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	shared := testNetwork("p", "default")
+	shared.Containers["sibling"] = moby.EndpointResource{Name: "other_web_1"}
+	api.networks = []moby.NetworkResource{shared, testNetwork("p", "back")}
+	tested := composeService{apiClient: api}
+
+	w := &recordingWriter{}
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:               testProject("p", "web"),
+		PreserveSharedDefault: true,
+		Result:                &result,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 1)
+	assert.Equal(t, api.networks[0].Name, "p_default")
+	assert.Equal(t, result.Networks, 1)
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_back"})
+	assert.DeepEqual(t, w.statusOf(`Network "p_default"`), []string{"Kept, used by 1 container(s) of other projects"})
+}
+
+func TestDownPreservesExternalDefaultNetwork(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	project := testProject("p", "web")
+	project.Networks = types.Networks{"default": types.NetworkConfig{Name: "p_default", External: types.External{External: true}}}
+	w := &recordingWriter{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{Project: project, PreserveSharedDefault: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 1)
+	assert.DeepEqual(t, w.statusOf(`Network "p_default"`), []string{"Kept, external"})
+}
+
+func TestDownRemovesUnsharedDefaultNetwork(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), PreserveSharedDefault: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+}
+
+func TestDownSharedDefaultNetworkWithoutOption(t *testing.T) {
+	api := newFakeClient()
+	shared := testNetwork("p", "default")
+	shared.Containers["sibling"] = moby.EndpointResource{Name: "other_web_1"}
+	api.networks = []moby.NetworkResource{shared}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, api.callsTo("NetworkDisconnect"), []string{"sibling"})
+}
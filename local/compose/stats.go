@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// statsTimeout bounds the time to collect the stats of a container, not to delay the teardown on a busy daemon
+var statsTimeout = 5 * time.Second
+
+// collectStats captures a snapshot of the resource usage of a running container, failures are only reported as warnings
+func (s *composeService) collectStats(ctx context.Context, container moby.Container, summary *downSummary) {
+	if container.State != "running" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+	name := getCanonicalContainerName(container)
+	stats, err := s.containerStats(ctx, container.ID)
+	if err != nil {
+		logrus.Warnf("failed to collect resource usage of container %s: %v", name, err)
+		return
+	}
+	summary.statsCollected(name, stats)
+}
+
+func (s *composeService) containerStats(ctx context.Context, id string) (compose.ContainerStats, error) {
+	response, err := s.apiClient.ContainerStats(ctx, id, false)
+	if err != nil {
+		return compose.ContainerStats{}, err
+	}
+	defer response.Body.Close() // nolint:errcheck
+	var stats moby.StatsJSON
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return compose.ContainerStats{}, err
+	}
+	return toContainerStats(stats), nil
+}
+
+func toContainerStats(stats moby.StatsJSON) compose.ContainerStats {
+	result := compose.ContainerStats{
+		CPUPercent:  cpuPercent(stats),
+		MemoryUsage: memoryUsage(stats.MemoryStats),
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+	for _, network := range stats.Networks {
+		result.NetworkRx += network.RxBytes
+		result.NetworkTx += network.TxBytes
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			result.BlockRead += entry.Value
+		case "write":
+			result.BlockWrite += entry.Value
+		}
+	}
+	return result
+}
+
+// cpuPercent computes the CPU usage between the two samples a stats snapshot holds, the way `docker stats` does
+func cpuPercent(stats moby.StatsJSON) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if systemDelta <= 0 || cpuDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage excludes the page cache the kernel can reclaim, as `docker stats` does
+func memoryUsage(memory moby.MemoryStats) uint64 {
+	// cgroup v1
+	if inactive, ok := memory.Stats["total_inactive_file"]; ok && inactive < memory.Usage {
+		return memory.Usage - inactive
+	}
+	if inactive := memory.Stats["inactive_file"]; inactive < memory.Usage {
+		return memory.Usage - inactive
+	}
+	return memory.Usage
+}
@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func testStats() moby.StatsJSON {
+	stats := moby.StatsJSON{
+		Networks: map[string]moby.NetworkStats{
+			"eth0": {RxBytes: 1000, TxBytes: 200},
+			"eth1": {RxBytes: 24, TxBytes: 56},
+		},
+	}
+	stats.CPUStats.CPUUsage.TotalUsage = 3000
+	stats.CPUStats.SystemUsage = 20000
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1000
+	stats.PreCPUStats.SystemUsage = 10000
+	stats.MemoryStats = moby.MemoryStats{Usage: 4096, Limit: 8192, Stats: map[string]uint64{"inactive_file": 1024}}
+	stats.BlkioStats.IoServiceBytesRecursive = []moby.BlkioStatEntry{
+		{Op: "Read", Value: 512},
+		{Op: "Write", Value: 128},
+		{Op: "read", Value: 512},
+		{Op: "Total", Value: 1152},
+	}
+	return stats
+}
+
+func TestDownCollectStats(t *testing.T) {
+	api := newFakeClient()
+	stopped := testContainer("p", "db", 1)
+	stopped.State = "exited"
+	api.containers = []moby.Container{testContainer("p", "web", 1), stopped}
+	api.stats["p_web_1"] = testStats()
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), CollectStats: true, Result: &result})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.Stats, map[string]compose.ContainerStats{
+		"p_web_1": {
+			CPUPercent:  40,
+			MemoryUsage: 3072,
+			MemoryLimit: 8192,
+			NetworkRx:   1024,
+			NetworkTx:   256,
+			BlockRead:   1024,
+			BlockWrite:  128,
+		},
+	})
+	// stopped containers have no usage to sample
+	assert.DeepEqual(t, api.callsTo("ContainerStats"), []string{"p_web_1"})
+	assert.Assert(t, api.callIndex("ContainerStats", "p_web_1") < api.callIndex("ContainerStop", "p_web_1"))
+}
+
+func TestDownCollectStatsFailureIsNotFatal(t *testing.T) {
+	defer func(timeout time.Duration) { statsTimeout = timeout }(statsTimeout)
+	statsTimeout = 20 * time.Millisecond
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1), testContainer("p", "cache", 1)}
+	api.stats["p_web_1"] = testStats()
+	api.statsDelays["p_db_1"] = time.Hour
+	api.errors["ContainerStats p_cache_1"] = fmt.Errorf("cgroup not found")
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db", "cache"), CollectStats: true, Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(result.Stats), 1)
+	assert.Equal(t, result.Stats["p_web_1"].MemoryLimit, uint64(8192))
+}
+
+func TestDownSkipsStatsByDefault(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Result: &result})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerStats")), 0)
+	assert.Assert(t, result.Stats == nil)
+}
+
+func TestCPUPercentWithoutPreviousSample(t *testing.T) {
+	stats := testStats()
+	stats.PreCPUStats = moby.CPUStats{}
+	stats.CPUStats.SystemUsage = 0
+	assert.Equal(t, cpuPercent(stats), float64(0))
+	stats = testStats()
+	stats.CPUStats.OnlineCPUs = 0
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{1500, 1500, 0, 0}
+	assert.Equal(t, cpuPercent(stats), float64(80))
+}
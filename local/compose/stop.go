@@ -44,7 +44,7 @@ func (s *composeService) Stop(ctx context.Context, project *types.Project, consu
 
 	err = InReverseDependencyOrder(ctx, project, func(c context.Context, service types.ServiceConfig) error {
 		serviceContainers, others := containers.split(isService(service.Name))
-		err := s.stopContainers(ctx, w, serviceContainers)
+		err := s.stopContainers(ctx, w, serviceContainers, 0)
 		containers = others
 		return err
 	})
@@ -0,0 +1,114 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+const (
+	defaultStopCheckInterval = time.Second
+	defaultStopCheckTimeout  = 30 * time.Second
+)
+
+// stopCheckPollInterval is the delay between inspections of a running stop check command
+var stopCheckPollInterval = 100 * time.Millisecond
+
+// checkReadyToStop runs the stop check command inside a running container until it succeeds. When it doesn't within
+// the timeout, the container is killed rather than gracefully stopped
+func (s *composeService) checkReadyToStop(ctx context.Context, w progress.Writer, container moby.Container, check compose.StopCheckOptions) error {
+	if container.State != "running" {
+		return nil
+	}
+	if check.Interval <= 0 {
+		check.Interval = defaultStopCheckInterval
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = defaultStopCheckTimeout
+	}
+	name := getCanonicalContainerName(container)
+	eventName := "Container " + name
+	w.Event(progress.NewEvent(eventName, progress.Working, "Checking ready to stop"))
+
+	checkCtx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+	err := s.retryStopCheck(checkCtx, container.ID, check)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	logrus.Warnf("container %s is still not ready to stop after %s, killing it: %v", name, check.Timeout, err)
+	w.Event(progress.NewEvent(eventName, progress.Working, "Killing"))
+	return s.apiClient.ContainerKill(ctx, container.ID, "SIGKILL")
+}
+
+// retryStopCheck runs the stop check command until it succeeds or the context is done, and returns the last failure
+func (s *composeService) retryStopCheck(ctx context.Context, id string, check compose.StopCheckOptions) error {
+	for {
+		err := s.runStopCheck(ctx, id, check.Command)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(check.Interval):
+		}
+	}
+}
+
+// runStopCheck executes a command inside a container, and waits for it to exit with status 0
+func (s *composeService) runStopCheck(ctx context.Context, id string, command []string) error {
+	exec, err := s.apiClient.ContainerExecCreate(ctx, id, moby.ExecConfig{
+		Cmd:    command,
+		Detach: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = s.apiClient.ContainerExecStart(ctx, exec.ID, moby.ExecStartCheck{Detach: true})
+	if err != nil {
+		return err
+	}
+	for {
+		inspect, err := s.apiClient.ContainerExecInspect(ctx, exec.ID)
+		if err != nil {
+			return err
+		}
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return errors.Errorf("stop check exited with status %d", inspect.ExitCode)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stopCheckPollInterval):
+		}
+	}
+}
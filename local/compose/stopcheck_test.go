@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownStopCheckSucceedsAfterRetries(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.execFailures["p_web_1"] = 2
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:   testProject("p", "web"),
+		StopCheck: &compose.StopCheckOptions{Command: []string{"/ready-to-stop"}, Interval: 10 * time.Millisecond},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerExecStart")), 3)
+	assert.Equal(t, len(api.callsTo("ContainerKill")), 0)
+	assert.Assert(t, api.callIndex("ContainerExecInspect", "exec_p_web_1") < api.callIndex("ContainerStop", "p_web_1"))
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownStopCheckTimeoutKillsContainer(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.execFailures["p_web_1"] = 1000
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		StopCheck: &compose.StopCheckOptions{
+			Command:  []string{"/ready-to-stop"},
+			Interval: 10 * time.Millisecond,
+			Timeout:  50 * time.Millisecond,
+		},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, len(api.callsTo("ContainerExecStart")) > 1)
+	killed := api.callIndex("ContainerKill", "p_web_1")
+	assert.Assert(t, killed >= 0 && killed < api.callIndex("ContainerRemove", "p_web_1"))
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownStopCheckSkipsStoppedContainers(t *testing.T) {
+	api := newFakeClient()
+	stopped := testContainer("p", "web", 1)
+	stopped.State = "exited"
+	api.containers = []moby.Container{stopped}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:   testProject("p", "web"),
+		StopCheck: &compose.StopCheckOptions{Command: []string{"/ready-to-stop"}},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerExecCreate")), 0)
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownStopCheckRequiresCommand(t *testing.T) {
+	tested := composeService{apiClient: newFakeClient()}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:   testProject("p", "web"),
+		StopCheck: &compose.StopCheckOptions{Timeout: time.Second},
+	})
+	assert.ErrorContains(t, err, "stop check command is required")
+}
@@ -0,0 +1,49 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// parallelRemovalStrategy is the default compose.RemovalStrategy, removing all resources of a kind in parallel
+type parallelRemovalStrategy struct{}
+
+func (parallelRemovalStrategy) RemoveContainers(ctx context.Context, service string, containers []string, remove func(context.Context, string) error) error {
+	return removeInParallel(ctx, containers, remove)
+}
+
+func (parallelRemovalStrategy) RemoveNetworks(ctx context.Context, networks []string, remove func(context.Context, string) error) error {
+	return removeInParallel(ctx, networks, remove)
+}
+
+func (parallelRemovalStrategy) RemoveVolumes(ctx context.Context, volumes []string, remove func(context.Context, string) error) error {
+	return removeInParallel(ctx, volumes, remove)
+}
+
+func removeInParallel(ctx context.Context, names []string, remove func(context.Context, string) error) error {
+	eg, _ := errgroup.WithContext(ctx)
+	for _, name := range names {
+		toRemove := name
+		eg.Go(func() error {
+			return remove(ctx, toRemove)
+		})
+	}
+	return eg.Wait()
+}
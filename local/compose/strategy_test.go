@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// sequentialStrategy removes resources one at a time, in reverse order, and records the removal order
+type sequentialStrategy struct {
+	mtx     sync.Mutex
+	removed []string
+}
+
+func (s *sequentialStrategy) removeAll(ctx context.Context, kind string, names []string, remove func(context.Context, string) error) error {
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := remove(ctx, names[i]); err != nil {
+			return err
+		}
+		s.mtx.Lock()
+		s.removed = append(s.removed, kind+" "+names[i])
+		s.mtx.Unlock()
+	}
+	return nil
+}
+
+func (s *sequentialStrategy) RemoveContainers(ctx context.Context, service string, containers []string, remove func(context.Context, string) error) error {
+	return s.removeAll(ctx, "container", containers, remove)
+}
+
+func (s *sequentialStrategy) RemoveNetworks(ctx context.Context, networks []string, remove func(context.Context, string) error) error {
+	return s.removeAll(ctx, "network", networks, remove)
+}
+
+func (s *sequentialStrategy) RemoveVolumes(ctx context.Context, volumes []string, remove func(context.Context, string) error) error {
+	return s.removeAll(ctx, "volume", volumes, remove)
+}
+
+func TestDownRemovalStrategy(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "front"), testNetwork("p", "back")}
+	tested := composeService{apiClient: api}
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", DependsOn: map[string]types.ServiceDependency{"db": {}}},
+			{Name: "db"},
+		},
+	}
+
+	strategy := &sequentialStrategy{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, RemovalStrategy: strategy})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, strategy.removed, []string{
+		"container p_web_2",
+		"container p_web_1",
+		"container p_db_1",
+		"network p_back",
+		"network p_front",
+	})
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_2", "p_web_1", "p_db_1"})
+	assert.DeepEqual(t, api.callsTo("NetworkRemove"), []string{"p_back", "p_front"})
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/sirupsen/logrus"
+)
+
+// ipamInspector tells whether an IPAM driver still holds a subnet
+type ipamInspector interface {
+	Allocated(ctx context.Context, driver string, subnet string) (bool, error)
+}
+
+// daemonIPAM infers the IPAM state from the networks the daemon knows about, as the engine API doesn't expose IPAM
+// drivers: a subnet is held as long as a network uses it
+type daemonIPAM struct {
+	apiClient client.APIClient
+}
+
+func (d daemonIPAM) Allocated(ctx context.Context, driver string, subnet string) (bool, error) {
+	networks, err := d.apiClient.NetworkList(ctx, moby.NetworkListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, n := range networks {
+		if ipamDriver(n.IPAM.Driver) != ipamDriver(driver) {
+			continue
+		}
+		for _, config := range n.IPAM.Config {
+			if config.Subnet == subnet {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func ipamDriver(driver string) string {
+	if driver == "" {
+		return "default"
+	}
+	return driver
+}
+
+// verifySubnetsReleased reports the static subnets of removed networks the IPAM driver still holds, so that they don't
+// conflict with the networks of a later up. Networks which still exist were kept on purpose and are not checked
+func (s *composeService) verifySubnetsReleased(ctx context.Context, project *types.Project, networks []moby.NetworkResource, summary *downSummary) {
+	inspector := s.ipam
+	if inspector == nil {
+		// subnets are allocated host wide, whatever the scope of the networks holding them. Built at use time, for
+		// the daemon to be reached through the rate limited client
+		hostClient := s.hostClient
+		if hostClient == nil {
+			hostClient = s.apiClient
+		}
+		inspector = daemonIPAM{apiClient: hostClient}
+	}
+	for _, n := range networks {
+		config, ok := project.Networks[n.Labels[networkLabel]]
+		if !ok || len(config.Ipam.Config) == 0 {
+			continue
+		}
+		_, err := s.apiClient.NetworkInspect(ctx, n.ID, moby.NetworkInspectOptions{})
+		if err == nil {
+			continue
+		}
+		if !errdefs.IsNotFound(err) {
+			logrus.Warnf("unable to verify subnets of network %s were released: %v", n.Name, err)
+			continue
+		}
+		driver := config.Ipam.Driver
+		if driver == "" {
+			driver = n.IPAM.Driver
+		}
+		for _, pool := range config.Ipam.Config {
+			allocated, err := inspector.Allocated(ctx, driver, pool.Subnet)
+			if err != nil {
+				logrus.Warnf("unable to verify subnet %s of network %s was released: %v", pool.Subnet, n.Name, err)
+				continue
+			}
+			if allocated {
+				logrus.Warnf("subnet %s of removed network %s is still allocated by the %s IPAM driver", pool.Subnet, n.Name, ipamDriver(driver))
+				summary.stuckSubnet(pool.Subnet)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// fakeIPAM reports the subnets it holds, recording the ones it is asked about
+type fakeIPAM struct {
+	allocated map[string]bool
+	queried   []string
+}
+
+func (f *fakeIPAM) Allocated(ctx context.Context, driver string, subnet string) (bool, error) {
+	f.queried = append(f.queried, driver+" "+subnet)
+	return f.allocated[subnet], nil
+}
+
+func staticSubnetProject(subnets ...string) *types.Project {
+	project := testProject("p", "web")
+	var pools []*types.IPAMPool
+	for _, subnet := range subnets {
+		pools = append(pools, &types.IPAMPool{Subnet: subnet})
+	}
+	project.Networks = types.Networks{
+		"default": types.NetworkConfig{Ipam: types.IPAMConfig{Config: pools}},
+		"dynamic": types.NetworkConfig{},
+	}
+	return project
+}
+
+func subnetNetwork(name string, subnet string) moby.NetworkResource {
+	n := testNetwork("p", name)
+	n.IPAM = network.IPAM{Driver: "default", Config: []network.IPAMConfig{{Subnet: subnet}}}
+	return n
+}
+
+func TestDownVerifySubnetsReleased(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{subnetNetwork("default", "172.30.0.0/16"), subnetNetwork("dynamic", "172.19.0.0/16")}
+	ipam := &fakeIPAM{allocated: map[string]bool{"172.30.0.0/16": true}}
+	tested := composeService{apiClient: api, ipam: ipam}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:               staticSubnetProject("172.30.0.0/16", "172.31.0.0/16"),
+		VerifySubnetsReleased: true,
+		Result:                &result,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	// dynamic subnets are not checked
+	assert.DeepEqual(t, ipam.queried, []string{"default 172.30.0.0/16", "default 172.31.0.0/16"})
+	assert.DeepEqual(t, result.StuckSubnets, []string{"172.30.0.0/16"})
+}
+
+func TestDownVerifySubnetsReleasedClean(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{subnetNetwork("default", "172.30.0.0/16")}
+	ipam := &fakeIPAM{allocated: map[string]bool{}}
+	tested := composeService{apiClient: api, ipam: ipam}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:               staticSubnetProject("172.30.0.0/16"),
+		VerifySubnetsReleased: true,
+		Result:                &result,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ipam.queried, []string{"default 172.30.0.0/16"})
+	assert.Equal(t, len(result.StuckSubnets), 0)
+}
+
+func TestVerifySubnetsSkipsKeptNetworks(t *testing.T) {
+	api := newFakeClient()
+	kept := subnetNetwork("default", "172.30.0.0/16")
+	api.networks = []moby.NetworkResource{kept}
+	ipam := &fakeIPAM{allocated: map[string]bool{"172.30.0.0/16": true}}
+	tested := composeService{apiClient: api, ipam: ipam}
+
+	summary := &downSummary{}
+	tested.verifySubnetsReleased(context.TODO(), staticSubnetProject("172.30.0.0/16"), []moby.NetworkResource{kept}, summary)
+	assert.DeepEqual(t, api.callsTo("NetworkInspect"), []string{"p_default"})
+	assert.Equal(t, len(ipam.queried), 0)
+	assert.Equal(t, len(summary.stuckSubnets), 0)
+}
+
+func TestDaemonIPAM(t *testing.T) {
+	api := newFakeClient()
+	other := subnetNetwork("other", "172.30.0.0/16")
+	other.Labels = nil
+	api.networks = []moby.NetworkResource{other}
+	ipam := daemonIPAM{apiClient: api}
+
+	allocated, err := ipam.Allocated(context.TODO(), "", "172.30.0.0/16")
+	assert.NilError(t, err)
+	assert.Assert(t, allocated)
+
+	allocated, err = ipam.Allocated(context.TODO(), "default", "172.31.0.0/16")
+	assert.NilError(t, err)
+	assert.Assert(t, !allocated)
+
+	// subnets are allocated per IPAM driver
+	allocated, err = ipam.Allocated(context.TODO(), "infoblox", "172.30.0.0/16")
+	assert.NilError(t, err)
+	assert.Assert(t, !allocated)
+}
+
+func TestDownVerifySubnetsReleasedThroughServiceClient(t *testing.T) {
+	api := newFakeClient()
+	other := subnetNetwork("other", "172.30.0.0/16")
+	other.Labels = nil
+	api.networks = []moby.NetworkResource{subnetNetwork("default", "172.30.0.0/16"), other}
+	tested := NewComposeService(api).(*composeService)
+	assert.Assert(t, tested.ipam == nil)
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:               staticSubnetProject("172.30.0.0/16"),
+		VerifySubnetsReleased: true,
+		RateLimit:             1000,
+		Result:                &result,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.StuckSubnets, []string{"172.30.0.0/16"})
+}
+
+func TestScopedDownVerifySubnetsReleasedHostWide(t *testing.T) {
+	api := newFakeClient()
+	mine, theirs := subnetNetwork("default", "172.30.0.0/16"), subnetNetwork("other", "172.30.0.0/16")
+	inScope("a")(mine.Labels)
+	theirs.Labels = map[string]string{"tenant": "b"}
+	api.networks = []moby.NetworkResource{mine, theirs}
+	tested := NewScopedComposeService(api, map[string]string{"tenant": "a"})
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:               staticSubnetProject("172.30.0.0/16"),
+		VerifySubnetsReleased: true,
+		RateLimit:             1000,
+		Result:                &result,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.networks, []moby.NetworkResource{theirs})
+	// the subnet is still held by a network out of the scope
+	assert.DeepEqual(t, result.StuckSubnets, []string{"172.30.0.0/16"})
+}
@@ -0,0 +1,197 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/compose-spec/compose-go/types"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// downSummary counts resources removed by a teardown, it is safe for concurrent use
+type downSummary struct {
+	containers int32
+	networks   int32
+	volumes    int32
+	reclaimed  int64
+
+	// volumeSizes and imageSizes are gathered before removal, when reclaimed space is reported
+	volumeSizes map[string]int64
+	imageSizes  map[string]int64
+
+	mtx                sync.Mutex
+	staleFirewallRules []string
+	committedImages    map[string]string
+	archivedImages     map[string]string
+	persistingVolumes  []string
+	removedImages      []string
+	heldPorts          []string
+	removedContainers  []string
+	keptServices       []string
+	stats              map[string]compose.ContainerStats
+	serviceImages      map[string]string
+	stuckSubnets       []string
+}
+
+func (s *downSummary) containerRemoved(id string) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt32(&s.containers, 1)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.removedContainers = append(s.removedContainers, id)
+}
+
+func (s *downSummary) removedContainerIDs() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string(nil), s.removedContainers...)
+}
+
+func (s *downSummary) serviceKept(service string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.keptServices = append(s.keptServices, service)
+}
+
+func (s *downSummary) servicesKept() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.keptServices) > 0
+}
+
+func (s *downSummary) networkRemoved() {
+	if s != nil {
+		atomic.AddInt32(&s.networks, 1)
+	}
+}
+
+func (s *downSummary) volumeRemoved(volume string) {
+	if s != nil {
+		atomic.AddInt32(&s.volumes, 1)
+		s.spaceReclaimed(s.volumeSizes[volume])
+	}
+}
+
+func (s *downSummary) imageDeleted(image string) {
+	if s != nil {
+		s.spaceReclaimed(s.imageSizes[image])
+	}
+}
+
+func (s *downSummary) spaceReclaimed(size int64) {
+	if s != nil && size > 0 {
+		atomic.AddInt64(&s.reclaimed, size)
+	}
+}
+
+func (s *downSummary) staleFirewallRule(rule string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.staleFirewallRules = append(s.staleFirewallRules, rule)
+}
+
+func (s *downSummary) stuckSubnet(subnet string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.stuckSubnets = append(s.stuckSubnets, subnet)
+}
+
+func (s *downSummary) containerCommitted(container string, image string) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.committedImages == nil {
+		s.committedImages = map[string]string{}
+	}
+	s.committedImages[container] = image
+}
+
+func (s *downSummary) imageArchived(image string, archive string) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.archivedImages == nil {
+		s.archivedImages = map[string]string{}
+	}
+	s.archivedImages[image] = archive
+}
+
+func (s *downSummary) volumePersisting(volume string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.persistingVolumes = append(s.persistingVolumes, volume)
+}
+
+func (s *downSummary) imageRemoved(image string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.removedImages = append(s.removedImages, image)
+}
+
+func (s *downSummary) portHeld(port string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.heldPorts = append(s.heldPorts, port)
+}
+
+func (s *downSummary) statsCollected(container string, stats compose.ContainerStats) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.stats == nil {
+		s.stats = map[string]compose.ContainerStats{}
+	}
+	s.stats[container] = stats
+}
+
+// projectSnapshot records the images of the project services, for the next teardown to be compared with
+func (s *downSummary) projectSnapshot(project *types.Project) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.serviceImages = serviceImages(project)
+}
+
+func (s *downSummary) result() compose.DownResult {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return compose.DownResult{
+		Containers:         int(atomic.LoadInt32(&s.containers)),
+		Networks:           int(atomic.LoadInt32(&s.networks)),
+		Volumes:            int(atomic.LoadInt32(&s.volumes)),
+		StaleFirewallRules: s.staleFirewallRules,
+		CommittedImages:    s.committedImages,
+		ArchivedImages:     s.archivedImages,
+		PersistingVolumes:  s.persistingVolumes,
+		RemovedImages:      s.removedImages,
+		HeldPorts:          s.heldPorts,
+		ReclaimedSpace:     atomic.LoadInt64(&s.reclaimed),
+		Stats:              s.stats,
+		ServiceImages:      s.serviceImages,
+		StuckSubnets:       s.stuckSubnets,
+	}
+}
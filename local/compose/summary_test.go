@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// TestDownSummaryConcurrency is meaningful when run with the race detector
+func TestDownSummaryConcurrency(t *testing.T) {
+	api := newFakeClient()
+	project := &types.Project{Name: "p"}
+	images := map[string]string{}
+	for i := 0; i < 20; i++ {
+		service := fmt.Sprintf("service%d", i)
+		project.Services = append(project.Services, types.ServiceConfig{Name: service, Image: service + ":latest"})
+		images[service] = service + ":latest"
+		for j := 1; j <= 5; j++ {
+			api.containers = append(api.containers, testContainer("p", service, j))
+		}
+		api.networks = append(api.networks, testNetwork("p", service))
+	}
+	for j := 1; j <= 5; j++ {
+		api.containers = append(api.containers, testContainer("p", "orphan", j))
+	}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:       project,
+		RemoveOrphans: true,
+		Result:        &result,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, compose.DownResult{Containers: 105, Networks: 20, ServiceImages: images})
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.callsTo("ContainerStop")), 105)
+}
+
+func TestDownSummaryPartialFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2)}
+	api.errors["ContainerRemove p_web_2"] = fmt.Errorf("boom")
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Result: &result})
+	assert.ErrorContains(t, err, "boom")
+	assert.DeepEqual(t, result, compose.DownResult{Containers: 1, ServiceImages: map[string]string{"web": ""}})
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/compose-spec/compose-go/types"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// decideServiceTeardown consults the ServiceTeardown callback about a service, and returns the options its containers
+// are to be removed with, or false when the service is to be kept
+func (s *composeService) decideServiceTeardown(ctx context.Context, w progress.Writer, service types.ServiceConfig, containers Containers,
+	options compose.DownOptions, summary *downSummary) (compose.DownOptions, bool, error) {
+	if options.ServiceTeardown == nil || len(containers) == 0 {
+		return options, true, nil
+	}
+	decision, err := options.ServiceTeardown(service, containers.names())
+	if err != nil {
+		return options, false, err
+	}
+	if decision.Skip {
+		summary.serviceKept(service.Name)
+		w.Event(progress.NewEvent("Service "+service.Name, progress.Done, "Kept"))
+		return options, false, nil
+	}
+	if decision.ForceKill {
+		if err := s.killContainers(ctx, w, containers); err != nil {
+			return options, false, err
+		}
+		// killed containers have nothing left to drain
+		options.Drain = nil
+	}
+	return options, true, nil
+}
+
+// killContainers sends SIGKILL to running containers
+func (s *composeService) killContainers(ctx context.Context, w progress.Writer, containers Containers) error {
+	for _, container := range containers {
+		if container.State != "running" {
+			continue
+		}
+		eventName := "Container " + getCanonicalContainerName(container)
+		w.Event(progress.NewEvent(eventName, progress.Working, "Killing"))
+		err := s.apiClient.ContainerKill(ctx, container.ID, "SIGKILL")
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, "Error while Killing"))
+			return err
+		}
+		w.Event(progress.NewEvent(eventName, progress.Working, "Killed"))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownServiceTeardownSkip(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web", "db"),
+		ServiceTeardown: func(service types.ServiceConfig, containers []string) (compose.TeardownDecision, error) {
+			return compose.TeardownDecision{Skip: service.Name == "db"}, nil
+		},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, api.callsTo("ContainerRemove"), []string{"p_web_1"})
+	assert.Equal(t, len(api.callsTo("ContainerStop")), 1)
+	// db still relies on the project network
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownServiceTeardownForceKill(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web", "db"),
+		Drain:   &compose.DrainOptions{Signal: "SIGUSR1", GracePeriod: time.Hour},
+		ServiceTeardown: func(service types.ServiceConfig, containers []string) (compose.TeardownDecision, error) {
+			return compose.TeardownDecision{ForceKill: true}, nil
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	for _, name := range []string{"p_web_1", "p_db_1"} {
+		killed := api.callIndex("ContainerKill", name)
+		assert.Assert(t, killed >= 0 && killed < api.callIndex("ContainerRemove", name), "%s should be killed before it is removed", name)
+	}
+	// killed containers are not drained
+	assert.Equal(t, len(api.callsTo("ContainerKill")), 2)
+}
+
+func TestDownServiceTeardownReceivesContainers(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "web", 2), testContainer("p", "db", 1)}
+	tested := composeService{apiClient: api}
+
+	var mtx sync.Mutex
+	consulted := map[string][]string{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web", "db", "cache"),
+		ServiceTeardown: func(service types.ServiceConfig, containers []string) (compose.TeardownDecision, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			consulted[service.Name] = containers
+			return compose.TeardownDecision{}, nil
+		},
+	})
+	assert.NilError(t, err)
+	// services without containers are not consulted
+	assert.DeepEqual(t, consulted, map[string][]string{
+		"web": {"p_web_1", "p_web_2"},
+		"db":  {"p_db_1"},
+	})
+	assert.Equal(t, len(api.containers), 0)
+}
+
+func TestDownServiceTeardownFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project: testProject("p", "web"),
+		ServiceTeardown: func(service types.ServiceConfig, containers []string) (compose.TeardownDecision, error) {
+			return compose.TeardownDecision{}, fmt.Errorf("service %s is locked", service.Name)
+		},
+	})
+	assert.ErrorContains(t, err, "service web is locked")
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, len(api.callsTo("ContainerStop")), 0)
+}
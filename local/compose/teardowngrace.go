@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// graceContext carries the values of its parent, but is only done once its parent has been done for a grace period
+type graceContext struct {
+	context.Context
+	grace time.Duration
+	done  chan struct{}
+	mtx   sync.Mutex
+	err   error
+}
+
+// withTeardownGrace returns a context for the destructive phase of the teardown, which outlives the cancellation of
+// ctx by the grace period so that the project isn't left half removed. It returns ctx itself without grace period.
+// Stopping the returned context waits for the interruption, if any, to be reported
+func withTeardownGrace(ctx context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	if grace <= 0 {
+		return ctx, func() {}
+	}
+	c := &graceContext{Context: ctx, grace: grace, done: make(chan struct{})}
+	stop := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		c.watch(stop)
+	}()
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			close(stop)
+		})
+		<-watched
+	}
+}
+
+// watch cancels the context once its parent has been done for the grace period, or when stopped
+func (c *graceContext) watch(stop chan struct{}) {
+	select {
+	case <-stop:
+		c.cancel(context.Canceled)
+		return
+	case <-c.Context.Done():
+	}
+
+	w := progress.ContextWriter(c.Context)
+	eventName := "Teardown"
+	w.Event(progress.NewEvent(eventName, progress.Working, fmt.Sprintf("Interrupted, finishing within %s", c.grace)))
+	timer := time.NewTimer(c.grace)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		w.Event(progress.NewEvent(eventName, progress.Done, "Finished despite interruption"))
+		c.cancel(context.Canceled)
+	case <-timer.C:
+		w.Event(progress.ErrorMessageEvent(eventName, "Grace period elapsed"))
+		c.cancel(c.Context.Err())
+	}
+}
+
+func (c *graceContext) cancel(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
+
+// Deadline extends the deadline of the parent by the grace period
+func (c *graceContext) Deadline() (time.Time, bool) {
+	deadline, ok := c.Context.Deadline()
+	if !ok {
+		return deadline, false
+	}
+	return deadline.Add(c.grace), true
+}
+
+func (c *graceContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *graceContext) Err() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.err
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownTeardownGraceCompletesAfterCancel(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.stopDelays["p_web_1"] = 200 * time.Millisecond
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx, cancel := context.WithCancel(progress.WithContextWriter(context.TODO(), w))
+	defer cancel()
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), TeardownGracePeriod: 5 * time.Second})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Equal(t, len(api.networks), 0)
+	assert.DeepEqual(t, w.statusOf("Teardown"), []string{"Interrupted, finishing within 5s", "Finished despite interruption"})
+}
+
+func TestDownWithoutTeardownGraceInterrupted(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.stopDelays["p_web_1"] = 5 * time.Second
+	tested := composeService{apiClient: api}
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	assert.Equal(t, len(api.networks), 1)
+}
+
+func TestDownTeardownGraceElapsed(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.stopDelays["p_web_1"] = 5 * time.Second
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+	ctx, cancel := context.WithCancel(progress.WithContextWriter(context.TODO(), w))
+	defer cancel()
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), TeardownGracePeriod: 50 * time.Millisecond})
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	assert.Assert(t, time.Since(start) < 2*time.Second)
+	assert.DeepEqual(t, w.statusOf("Teardown"), []string{"Interrupted, finishing within 50ms", "Grace period elapsed"})
+}
+
+type graceKey struct{}
+
+func TestTeardownGraceContext(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	parent, cancelParent := context.WithDeadline(context.WithValue(context.TODO(), graceKey{}, "value"), deadline)
+	ctx, stop := withTeardownGrace(parent, time.Minute)
+
+	assert.Equal(t, ctx.Value(graceKey{}), "value")
+	extended, ok := ctx.Deadline()
+	assert.Assert(t, ok)
+	assert.Equal(t, extended, deadline.Add(time.Minute))
+
+	cancelParent()
+	select {
+	case <-ctx.Done():
+		t.Fatal("teardown context should outlive its parent")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.NilError(t, ctx.Err())
+
+	stop()
+	stop()
+	<-ctx.Done()
+	assert.Equal(t, ctx.Err(), context.Canceled)
+}
+
+func TestTeardownGraceDisabled(t *testing.T) {
+	parent := context.TODO()
+	ctx, stop := withTeardownGrace(parent, 0)
+	defer stop()
+	assert.Equal(t, ctx, parent)
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	volume_api "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+// tombstoneName is the name of the volume recording a project teardown. A volume is the lightest daemon-side record,
+// it doesn't need an image and isn't labeled as a project resource so it survives the project cleanup
+func tombstoneName(projectName string) string {
+	return fmt.Sprintf("%s_compose_tombstone", projectName)
+}
+
+func (s *composeService) createTombstone(ctx context.Context, projectName string) error {
+	name := tombstoneName(projectName)
+	// labels of an existing volume can't be updated
+	if err := s.apiClient.VolumeRemove(ctx, name, true); err != nil && !errdefs.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to replace tombstone of project %s", projectName)
+	}
+	_, err := s.apiClient.VolumeCreate(ctx, volume_api.VolumeCreateBody{
+		Name:   name,
+		Driver: "local",
+		Labels: map[string]string{
+			tombstoneLabel:     projectName,
+			tombstoneTimeLabel: time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create tombstone of project %s", projectName)
+	}
+	return nil
+}
+
+func (s *composeService) Tombstone(ctx context.Context, projectName string) (*compose.Tombstone, error) {
+	containers, err := s.apiClient.ContainerList(ctx, moby.ContainerListOptions{
+		Filters: filters.NewArgs(projectFilter(projectName)),
+		All:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// project was brought up again
+	if len(containers) > 0 {
+		return nil, nil
+	}
+	list, err := s.apiClient.VolumeList(ctx, filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", tombstoneLabel, projectName))))
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Volumes) == 0 {
+		return nil, nil
+	}
+	removedAt, err := time.Parse(time.RFC3339, list.Volumes[0].Labels[tombstoneTimeLabel])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid tombstone of project %s", projectName)
+	}
+	return &compose.Tombstone{Project: projectName, RemovedAt: removedAt}, nil
+}
@@ -0,0 +1,72 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownTombstone(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	tombstone, err := tested.Tombstone(context.TODO(), "p")
+	assert.NilError(t, err)
+	assert.Assert(t, tombstone == nil)
+
+	err = tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Tombstone: true})
+	assert.NilError(t, err)
+	tombstone, err = tested.Tombstone(context.TODO(), "p")
+	assert.NilError(t, err)
+	assert.Equal(t, tombstone.Project, "p")
+	assert.Assert(t, time.Since(tombstone.RemovedAt) < time.Minute)
+
+	// was never torn down
+	tombstone, err = tested.Tombstone(context.TODO(), "other")
+	assert.NilError(t, err)
+	assert.Assert(t, tombstone == nil)
+
+	// brought up again
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tombstone, err = tested.Tombstone(context.TODO(), "p")
+	assert.NilError(t, err)
+	assert.Assert(t, tombstone == nil)
+}
+
+func TestDownTombstoneReplaced(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{{
+		Name:   tombstoneName("p"),
+		Labels: map[string]string{tombstoneLabel: "p", tombstoneTimeLabel: "2020-01-01T00:00:00Z"},
+	}}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Tombstone: true, Volumes: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.volumes), 1)
+	tombstone, err := tested.Tombstone(context.TODO(), "p")
+	assert.NilError(t, err)
+	assert.Assert(t, tombstone.RemovedAt.After(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	"github.com/pkg/errors"
+	"github.com/sanathkr/go-yaml"
+)
+
+// undoHeredocDelimiter ends the compose model embedded in the undo script
+const undoHeredocDelimiter = "COMPOSE_UNDO_EOF"
+
+// writeUndoScript writes a shell script recreating the project from its model, as a manual undo path after a
+// teardown. The script can only recreate what the model describes: volumes data and images built locally are lost
+func writeUndoScript(path string, project *types.Project) error {
+	script, err := undoScript(project, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate undo script for project %s", project.Name)
+	}
+	if err := ioutil.WriteFile(path, []byte(script), 0700); err != nil {
+		return errors.Wrapf(err, "failed to write undo script for project %s", project.Name)
+	}
+	return nil
+}
+
+func undoScript(project *types.Project, now time.Time) (string, error) {
+	model, err := yaml.Marshal(project)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(model), "\n") {
+		if line == undoHeredocDelimiter {
+			return "", errors.Errorf("compose model contains the %s delimiter", undoHeredocDelimiter)
+		}
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "#!/bin/sh")
+	fmt.Fprintf(b, "# Recreates project %s torn down on %s\n", project.Name, now.UTC().Format(time.RFC3339))
+	for _, service := range project.Services {
+		fmt.Fprintf(b, "# service %s: image %s\n", service.Name, service.Image)
+	}
+	for _, name := range networkNames(project.Networks) {
+		fmt.Fprintf(b, "# network %s\n", name)
+	}
+	fmt.Fprintln(b, "set -e")
+	fmt.Fprintln(b, `model="$(mktemp)"`)
+	fmt.Fprintln(b, `trap 'rm -f "$model"' EXIT`)
+	fmt.Fprintf(b, "cat > \"$model\" <<'%s'\n", undoHeredocDelimiter)
+	b.Write(model)
+	if !strings.HasSuffix(string(model), "\n") {
+		fmt.Fprintln(b)
+	}
+	fmt.Fprintln(b, undoHeredocDelimiter)
+	fmt.Fprintf(b, "docker compose --project-name %s --file \"$model\" up --detach\n", shellQuote(project.Name))
+	return b.String(), nil
+}
+
+func networkNames(networks types.Networks) []string {
+	var names []string
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func undoProject() *types.Project {
+	return &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "web", Image: "nginx:1.19", Networks: map[string]*types.ServiceNetworkConfig{"front": nil}},
+			{Name: "db", Image: "postgres:13", Networks: map[string]*types.ServiceNetworkConfig{"back": nil}},
+		},
+		Networks: types.Networks{
+			"front": types.NetworkConfig{Name: "p_front"},
+			"back":  types.NetworkConfig{Name: "p_back"},
+		},
+	}
+}
+
+func TestUndoScript(t *testing.T) {
+	script, err := undoScript(undoProject(), time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(script, "#!/bin/sh\n# Recreates project p torn down on 2021-02-01T10:00:00Z\n"))
+	for _, expected := range []string{
+		"# service web: image nginx:1.19\n",
+		"# service db: image postgres:13\n",
+		"# network back\n# network front\n",
+		"image: nginx:1.19\n",
+		"image: postgres:13\n",
+		"name: p_front\n",
+		"name: p_back\n",
+		"\ndocker compose --project-name 'p' --file \"$model\" up --detach\n",
+	} {
+		assert.Assert(t, strings.Contains(script, expected), expected)
+	}
+}
+
+func TestUndoScriptQuotesProjectName(t *testing.T) {
+	script, err := undoScript(&types.Project{Name: "it's"}, time.Now())
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(script, `--project-name 'it'\''s'`))
+}
+
+func TestDownWritesUndoScript(t *testing.T) {
+	dir := fs.NewDir(t, "undo")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerRemove p_web_1"] = fmt.Errorf("device busy")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: undoProject(), UndoScript: dir.Join("undo.sh")})
+	assert.ErrorContains(t, err, "device busy")
+	// script is written before anything is removed
+	script, err := ioutil.ReadFile(dir.Join("undo.sh"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(script), "# service web: image nginx:1.19\n"))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(dir.Join("undo.sh"))
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0700))
+	}
+}
+
+func TestDownUndoScriptFailurePreventsTeardown(t *testing.T) {
+	dir := fs.NewDir(t, "undo")
+	defer dir.Remove()
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: undoProject(), UndoScript: dir.Join("missing", "undo.sh")})
+	assert.ErrorContains(t, err, "failed to write undo script for project p")
+	assert.Equal(t, len(api.callsTo("ContainerStop")), 0)
+	assert.Equal(t, len(api.containers), 1)
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// volumesWithDrivers selects the volumes using one of the drivers, and reports the volumes kept
+func (s *composeService) volumesWithDrivers(ctx context.Context, volumes []string, drivers []string) ([]string, error) {
+	w := progress.ContextWriter(ctx)
+	var selected []string
+	for _, name := range volumes {
+		volume, err := s.apiClient.VolumeInspect(ctx, name)
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to inspect volume %s", name)
+		}
+		if !contains(drivers, volume.Driver) {
+			w.Event(progress.NewEvent(fmt.Sprintf("Volume %q", name), progress.Done, fmt.Sprintf("Kept, %s driver not selected", volume.Driver)))
+			continue
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
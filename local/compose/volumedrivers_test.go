@@ -0,0 +1,80 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+func TestDownVolumeDrivers(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	shared := testVolume("p", "shared", 0)
+	shared.Driver = "nfs"
+	api.volumes = []moby.Volume{testVolume("p", "data", 0), shared, testVolume("p", "cache", 0)}
+	tested := composeService{apiClient: api}
+	w := &recordingWriter{}
+
+	result := compose.DownResult{}
+	err := tested.Down(progress.WithContextWriter(context.TODO(), w), "p", compose.DownOptions{
+		Project:       testProject("p", "web"),
+		Volumes:       true,
+		VolumeDrivers: []string{"local"},
+		Result:        &result,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Volumes, 2)
+	assert.DeepEqual(t, api.volumes, []moby.Volume{shared})
+	assert.DeepEqual(t, w.statusOf(`Volume "p_shared"`), []string{"Kept, nfs driver not selected"})
+	assert.Equal(t, len(api.callsTo("VolumeInspect")), 3)
+}
+
+func TestDownVolumeDriversInspectFailure(t *testing.T) {
+	api := newFakeClient()
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	api.errors["VolumeInspect p_data"] = fmt.Errorf("plugin not responding")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:       testProject("p", "web"),
+		Volumes:       true,
+		VolumeDrivers: []string{"local"},
+	})
+	assert.ErrorContains(t, err, "failed to inspect volume p_data: plugin not responding")
+	assert.Equal(t, len(api.volumes), 1)
+}
+
+func TestDownAllVolumeDriversByDefault(t *testing.T) {
+	api := newFakeClient()
+	shared := testVolume("p", "shared", 0)
+	shared.Driver = "nfs"
+	api.volumes = []moby.Volume{testVolume("p", "data", 0), shared}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.volumes), 0)
+	assert.Equal(t, len(api.callsTo("VolumeInspect")), 0)
+}
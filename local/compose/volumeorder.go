@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"github.com/compose-spec/compose-go/types"
+)
+
+// withVolumeOrdering returns a copy of the project where services reading a named volume depend on the services
+// writing to it, so readers are removed before the writer, as in the init-container pattern. This is a heuristic:
+// compose doesn't formalize such ordering, a service mounting a volume read-only is considered a reader and one
+// mounting it read-write a writer. Volumes with several writers are ignored, as are dependencies which would create
+// a cycle
+func withVolumeOrdering(project *types.Project) *types.Project {
+	readers := map[string][]string{}
+	writers := map[string][]string{}
+	var volumes []string
+	for _, service := range project.Services {
+		for _, v := range service.Volumes {
+			if v.Type != types.VolumeTypeVolume || v.Source == "" {
+				continue
+			}
+			if len(readers[v.Source])+len(writers[v.Source]) == 0 {
+				volumes = append(volumes, v.Source)
+			}
+			if v.ReadOnly {
+				readers[v.Source] = append(readers[v.Source], service.Name)
+			} else {
+				writers[v.Source] = append(writers[v.Source], service.Name)
+			}
+		}
+	}
+
+	ordered := *project
+	ordered.Services = append(types.Services{}, project.Services...)
+	for _, volume := range volumes {
+		if len(writers[volume]) != 1 {
+			continue
+		}
+		writer := writers[volume][0]
+		for _, reader := range readers[volume] {
+			if reader == writer || dependsOn(&ordered, writer, reader, map[string]bool{}) {
+				continue
+			}
+			addDependency(&ordered, reader, writer)
+		}
+	}
+	return &ordered
+}
+
+// dependsOn tells if a service depends on another, directly or transitively
+func dependsOn(project *types.Project, service string, dependency string, seen map[string]bool) bool {
+	if seen[service] {
+		return false
+	}
+	seen[service] = true
+	config, err := project.GetService(service)
+	if err != nil {
+		return false
+	}
+	for _, name := range append(config.GetDependencies(), linkedServices(config)...) {
+		if name == dependency || dependsOn(project, name, dependency, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// addDependency makes a service depend on another, without altering the original service configuration
+func addDependency(project *types.Project, service string, dependency string) {
+	for i, s := range project.Services {
+		if s.Name != service {
+			continue
+		}
+		deps := types.DependsOnConfig{}
+		for name, d := range s.DependsOn {
+			deps[name] = d
+		}
+		if _, ok := deps[dependency]; !ok {
+			deps[dependency] = types.ServiceDependency{}
+		}
+		s.DependsOn = deps
+		project.Services[i] = s
+	}
+}
@@ -0,0 +1,105 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func volumeMount(source string, readOnly bool) types.ServiceVolumeConfig {
+	return types.ServiceVolumeConfig{Type: types.VolumeTypeVolume, Source: source, Target: "/data", ReadOnly: readOnly}
+}
+
+func TestWithVolumeOrdering(t *testing.T) {
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "app", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", true)}},
+			{Name: "init", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", false)}},
+		},
+	}
+
+	ordered := withVolumeOrdering(project)
+	app, err := ordered.GetService("app")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, app.GetDependencies(), []string{"init"})
+	writer, err := ordered.GetService("init")
+	assert.NilError(t, err)
+	assert.Equal(t, len(writer.GetDependencies()), 0)
+	// project model is left untouched
+	assert.Equal(t, len(project.Services[0].DependsOn), 0)
+}
+
+func TestWithVolumeOrderingSkipsAmbiguousVolumes(t *testing.T) {
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "app", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", true), volumeMount("cache", false)}},
+			{Name: "init", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", false)}},
+			{Name: "other", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", false), volumeMount("cache", false)}},
+		},
+	}
+
+	ordered := withVolumeOrdering(project)
+	for _, service := range ordered.Services {
+		assert.Equal(t, len(service.GetDependencies()), 0, service.Name)
+	}
+}
+
+func TestWithVolumeOrderingAvoidsCycles(t *testing.T) {
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "app", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", true)}},
+			{
+				Name:      "init",
+				DependsOn: map[string]types.ServiceDependency{"app": {}},
+				Volumes:   []types.ServiceVolumeConfig{volumeMount("assets", false)},
+			},
+		},
+	}
+
+	ordered := withVolumeOrdering(project)
+	app, err := ordered.GetService("app")
+	assert.NilError(t, err)
+	assert.Equal(t, len(app.GetDependencies()), 0)
+}
+
+func TestDownInferVolumeOrder(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "init", 1), testContainer("p", "app", 1)}
+	tested := composeService{apiClient: api}
+
+	project := &types.Project{
+		Name: "p",
+		Services: []types.ServiceConfig{
+			{Name: "init", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", false)}},
+			{Name: "app", Volumes: []types.ServiceVolumeConfig{volumeMount("assets", true)}},
+		},
+	}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: project, InferVolumeOrder: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	assert.Assert(t, api.callIndex("ContainerRemove", "p_app_1") < api.callIndex("ContainerStop", "p_init_1"))
+}
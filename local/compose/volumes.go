@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose-cli/api/compose"
+	"github.com/docker/compose-cli/api/progress"
+)
+
+// anonymousVolumeName matches the random name the engine assigns to anonymous volumes
+var anonymousVolumeName = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// anonymousVolumes returns the names of anonymous volumes attached to containers
+func (containers Containers) anonymousVolumes() []string {
+	var volumes []string
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Type == mount.TypeVolume && anonymousVolumeName.MatchString(m.Name) && !contains(volumes, m.Name) {
+				volumes = append(volumes, m.Name)
+			}
+		}
+	}
+	return volumes
+}
+
+// verifyVolumesRemoved checks anonymous volumes removed along with their containers no longer exist.
+// Persisting volumes are reported, but don't fail the teardown
+func (s *composeService) verifyVolumesRemoved(ctx context.Context, volumes []string, summary *downSummary) error {
+	for _, name := range volumes {
+		_, err := s.apiClient.VolumeInspect(ctx, name)
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		// anonymous volumes don't carry the scope labels, a scoped client refusing to inspect it still proves it exists
+		if err != nil && !errdefs.IsForbidden(err) {
+			return errors.Wrapf(err, "failed to verify volume %s was removed", name)
+		}
+		logrus.Warnf("anonymous volume %s still exists after its container was removed", name)
+		summary.volumePersisting(name)
+	}
+	return nil
+}
+
+// removeVolumes removes the project volumes
+func (s *composeService) removeVolumes(ctx context.Context, projectName string, options compose.DownOptions, summary *downSummary) error {
+	list, err := s.apiClient.VolumeList(ctx, filters.NewArgs(projectFilter(projectName)))
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, v := range list.Volumes {
+		names = append(names, v.Name)
+	}
+	if len(options.VolumeDrivers) > 0 {
+		names, err = s.volumesWithDrivers(ctx, names, options.VolumeDrivers)
+		if err != nil {
+			return err
+		}
+	}
+	return options.RemovalStrategy.RemoveVolumes(ctx, names, func(ctx context.Context, name string) error {
+		// volumes are identified by their name
+		return s.ensureVolumeDown(withResourceID(ctx, fmt.Sprintf("Volume %q", name), name), name, summary)
+	})
+}
+
+func (s *composeService) ensureVolumeDown(ctx context.Context, volumeName string, summary *downSummary) error {
+	w := progress.ContextWriter(ctx)
+	eventName := fmt.Sprintf("Volume %q", volumeName)
+	w.Event(progress.RemovingEvent(eventName))
+
+	err := s.apiClient.VolumeRemove(ctx, volumeName, false)
+	if err != nil && !errdefs.IsNotFound(err) {
+		w.Event(progress.ErrorEvent(eventName))
+		return errors.Wrapf(err, "failed to remove volume %s", volumeName)
+	}
+
+	w.Event(progress.RemovedEvent(eventName))
+	summary.volumeRemoved(volumeName)
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func anonymousVolume(c string) (moby.Volume, moby.MountPoint) {
+	name := strings.Repeat(c, 64)
+	return moby.Volume{Name: name, Driver: "local"}, moby.MountPoint{Type: mount.TypeVolume, Name: name, Destination: "/data"}
+}
+
+func TestAnonymousVolumes(t *testing.T) {
+	_, anonymous := anonymousVolume("a")
+	web := testContainer("p", "web", 1)
+	web.Mounts = []moby.MountPoint{
+		anonymous,
+		{Type: mount.TypeVolume, Name: "p_data", Destination: "/named"},
+		{Type: mount.TypeBind, Source: "/src", Destination: "/src"},
+	}
+	worker := testContainer("p", "worker", 1)
+	worker.Mounts = []moby.MountPoint{anonymous}
+
+	assert.DeepEqual(t, Containers{web, worker}.anonymousVolumes(), []string{anonymous.Name})
+}
+
+func TestDownVolumes(t *testing.T) {
+	api := newFakeClient()
+	volume, anonymous := anonymousVolume("a")
+	web := testContainer("p", "web", 1)
+	web.Mounts = []moby.MountPoint{anonymous}
+	api.containers = []moby.Container{web}
+	api.volumes = []moby.Volume{volume, testVolume("p", "data", 0), testVolume("other", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:              testProject("p", "web"),
+		Volumes:              true,
+		VerifyVolumesRemoved: true,
+		Result:               &result,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.volumes), 1)
+	assert.Equal(t, api.volumes[0].Name, "other_data")
+	assert.Equal(t, result.Volumes, 1)
+	assert.Equal(t, len(result.PersistingVolumes), 0)
+	assert.DeepEqual(t, api.callsTo("VolumeInspect"), []string{volume.Name})
+}
+
+func TestDownReportsPersistingAnonymousVolume(t *testing.T) {
+	api := newFakeClient()
+	leaked, leakedMount := anonymousVolume("b")
+	removed, removedMount := anonymousVolume("c")
+	web := testContainer("p", "web", 1)
+	web.Mounts = []moby.MountPoint{leakedMount, removedMount}
+	api.containers = []moby.Container{web}
+	api.volumes = []moby.Volume{leaked, removed}
+	api.leaked[leaked.Name] = true
+	tested := composeService{apiClient: api}
+
+	result := compose.DownResult{}
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:              testProject("p", "web"),
+		Volumes:              true,
+		VerifyVolumesRemoved: true,
+		Result:               &result,
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result.PersistingVolumes, []string{leaked.Name})
+}
+
+func TestDownKeepsVolumesByDefault(t *testing.T) {
+	api := newFakeClient()
+	volume, anonymous := anonymousVolume("d")
+	web := testContainer("p", "web", 1)
+	web.Mounts = []moby.MountPoint{anonymous}
+	api.containers = []moby.Container{web}
+	api.volumes = []moby.Volume{volume, testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), VerifyVolumesRemoved: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.volumes), 2)
+	assert.Equal(t, len(api.callsTo("VolumeInspect")), 0)
+}
+
+func TestDownResourceOrder(t *testing.T) {
+	for _, order := range []compose.ResourceOrder{compose.NetworksFirst, compose.VolumesFirst} {
+		api := newFakeClient()
+		api.containers = []moby.Container{testContainer("p", "web", 1)}
+		api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+		api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+		tested := composeService{apiClient: api}
+
+		err := tested.Down(context.TODO(), "p", compose.DownOptions{
+			Project:       testProject("p", "web"),
+			Volumes:       true,
+			ResourceOrder: order,
+		})
+		assert.NilError(t, err)
+		networkRemoved := api.callIndex("NetworkRemove", "p_default")
+		volumeRemoved := api.callIndex("VolumeRemove", "p_data")
+		assert.Assert(t, networkRemoved > api.callIndex("ContainerRemove", "p_web_1"))
+		assert.Assert(t, volumeRemoved > api.callIndex("ContainerRemove", "p_web_1"))
+		if order == compose.VolumesFirst {
+			assert.Assert(t, volumeRemoved < networkRemoved, "volumes should be removed before networks")
+		} else {
+			assert.Assert(t, networkRemoved < volumeRemoved, "networks should be removed before volumes")
+		}
+	}
+}
+
+// rendezvousStrategy removes networks only once volumes removal started, so that sequential removals time out
+type rendezvousStrategy struct {
+	parallelRemovalStrategy
+	volumesStarted chan struct{}
+}
+
+func (s rendezvousStrategy) RemoveNetworks(ctx context.Context, networks []string, remove func(context.Context, string) error) error {
+	select {
+	case <-s.volumesStarted:
+	case <-time.After(time.Second):
+		return fmt.Errorf("networks removal did not run concurrently with volumes removal")
+	}
+	return s.parallelRemovalStrategy.RemoveNetworks(ctx, networks, remove)
+}
+
+func (s rendezvousStrategy) RemoveVolumes(ctx context.Context, volumes []string, remove func(context.Context, string) error) error {
+	close(s.volumesStarted)
+	return s.parallelRemovalStrategy.RemoveVolumes(ctx, volumes, remove)
+}
+
+func TestDownRemovesNetworksAndVolumesConcurrently(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		Volumes:         true,
+		ResourceOrder:   compose.Concurrently,
+		RemovalStrategy: rendezvousStrategy{volumesStarted: make(chan struct{})},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.networks), 0)
+	assert.Equal(t, len(api.volumes), 0)
+}
+
+func TestDownConcurrentRemovalErrors(t *testing.T) {
+	api := newFakeClient()
+	api.networks = []moby.NetworkResource{testNetwork("p", "default")}
+	api.volumes = []moby.Volume{testVolume("p", "data", 0), testVolume("p", "cache", 0)}
+	api.errors["NetworkRemove p_default"] = fmt.Errorf("network is still in use")
+	api.errors["VolumeRemove p_data"] = fmt.Errorf("volume is in use")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), Volumes: true, ResourceOrder: compose.Concurrently})
+	assert.ErrorContains(t, err, "network is still in use")
+	assert.ErrorContains(t, err, "failed to remove volume p_data: volume is in use")
+	// a failure doesn't prevent the removal of the other kind of resources
+	assert.DeepEqual(t, api.volumes, []moby.Volume{testVolume("p", "data", 0)})
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/pkg/errors"
+)
+
+// defaultWaitStoppedTimeout bounds the wait for a stopped container to exit when no MaxStopDuration is set. The
+// container was already stopped, so the engine default stop timeout is more than it takes
+const defaultWaitStoppedTimeout = 10 * time.Second
+
+// waitStopped waits for the daemon to confirm a stopped container exited, as a container still shutting down can't
+// be reliably removed. The wait is bounded by timeout, or defaultWaitStoppedTimeout if not set
+func (s *composeService) waitStopped(ctx context.Context, c moby.Container, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultWaitStoppedTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resultC, errC := s.apiClient.ContainerWait(waitCtx, c.ID, container.WaitConditionNotRunning)
+	select {
+	case result := <-resultC:
+		if result.Error != nil {
+			return errors.Errorf("failed to wait for container %s to exit: %s", getCanonicalContainerName(c), result.Error.Message)
+		}
+		return nil
+	case err := <-errC:
+		if waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return errors.Errorf("failed to wait for container %s to exit: still running after %s", getCanonicalContainerName(c), timeout)
+		}
+		return errors.Wrapf(err, "failed to wait for container %s to exit", getCanonicalContainerName(c))
+	}
+}
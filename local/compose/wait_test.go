@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose-cli/api/compose"
+)
+
+func TestDownWaitStopped(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1), testContainer("p", "db", 1)}
+	api.exitDelays["p_web_1"] = 100 * time.Millisecond
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web", "db"), WaitStopped: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.containers), 0)
+	for _, name := range []string{"p_web_1", "p_db_1"} {
+		stopped := api.callIndex("ContainerStop", name)
+		wait := api.callIndex("ContainerWait", name+" not-running")
+		exited := api.callIndex("ContainerExited", name)
+		removed := api.callIndex("ContainerRemove", name)
+		assert.Assert(t, stopped >= 0 && stopped < wait && wait < exited && exited < removed, name)
+	}
+}
+
+func TestDownWithoutWaitStopped(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web")})
+	assert.NilError(t, err)
+	assert.Equal(t, len(api.callsTo("ContainerWait")), 0)
+}
+
+func TestDownWaitStoppedFailure(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.errors["ContainerWait p_web_1 not-running"] = fmt.Errorf("connection reset")
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{Project: testProject("p", "web"), WaitStopped: true})
+	assert.ErrorContains(t, err, "failed to wait for container p_web_1 to exit: connection reset")
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}
+
+func TestDownWaitStoppedCanceled(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.exitDelays["p_web_1"] = time.Minute
+	tested := composeService{apiClient: api}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	err := tested.Down(ctx, "p", compose.DownOptions{Project: testProject("p", "web"), WaitStopped: true})
+	assert.ErrorContains(t, err, "failed to wait for container p_web_1 to exit")
+	assert.Equal(t, len(api.containers), 1)
+}
+
+func TestDownWaitStoppedTimeout(t *testing.T) {
+	api := newFakeClient()
+	api.containers = []moby.Container{testContainer("p", "web", 1)}
+	api.exitDelays["p_web_1"] = time.Minute
+	tested := composeService{apiClient: api}
+
+	err := tested.Down(context.TODO(), "p", compose.DownOptions{
+		Project:         testProject("p", "web"),
+		WaitStopped:     true,
+		MaxStopDuration: 100 * time.Millisecond,
+	})
+	assert.ErrorContains(t, err, "failed to wait for container p_web_1 to exit: still running after 100ms")
+	assert.Equal(t, len(api.containers), 1)
+	assert.Equal(t, len(api.callsTo("ContainerRemove")), 0)
+}